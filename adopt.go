@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Unregistered returns the sorted names of the directories under PluginDir
+// that are not known plugins.
+func (p Plugins) Unregistered() []string {
+	unregistered := []string{}
+	for name := range PluginsOnDisk() {
+		if _, ok := p[name]; !ok {
+			unregistered = append(unregistered, name)
+		}
+	}
+	sort.Strings(unregistered)
+	return unregistered
+}
+
+// Adopt registers a clone found under PluginDir using its origin URL and
+// whatever it has checked out.
+func (p Plugins) Adopt(name string) (Plugin, error) {
+	if _, ok := p[name]; ok {
+		return Plugin{}, fmt.Errorf("%s: plugin is already registered", name)
+	}
+	plugin := Plugin{Name: name}
+	url, err := plugin.OriginURL()
+	if err != nil {
+		return Plugin{}, fmt.Errorf("failed to find origin url: %w", err)
+	}
+	plugin.URL = url
+	version, err := plugin.checkedOutVersion()
+	if err != nil {
+		return Plugin{}, err
+	}
+	return p.Add(url, name, version), nil
+}
+
+// checkedOutVersion returns the version a clone is on: nothing when it is on
+// the remote's default branch, otherwise the branch, tag or commit.
+func (plugin *Plugin) checkedOutVersion() (string, error) {
+	if branch, err := plugin.RunGit("symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		defaultBranch, err := plugin.RunGit("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+		if err == nil {
+			defaultBranch = strings.TrimPrefix(defaultBranch, "origin/")
+		} else if defaultBranch, err = plugin.DefaultBranch(); err != nil {
+			return "", err
+		}
+		if branch == defaultBranch {
+			return "", nil
+		}
+		return branch, nil
+	}
+	if tag, err := plugin.RunGit("describe", "--tags", "--exact-match", "HEAD"); err == nil {
+		return tag, nil
+	}
+	return plugin.CurrentCommit()
+}
@@ -0,0 +1,53 @@
+package tools_test
+
+import (
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestAdopt(t *testing.T) {
+	prepareGitEnv(t)
+	upstream := upstreamRepo(t, 3)
+	git(t, upstream, "branch", "dev", "v0.2")
+	root := git(t, upstream, "rev-list", "--max-parents=0", "HEAD")
+
+	clonePlugin(t, upstream, "registered")
+	clonePlugin(t, upstream, "default")
+	dev := clonePlugin(t, upstream, "dev")
+	git(t, dev.Dir(), "checkout", "--quiet", "-b", "dev", "--track", "origin/dev")
+	tag := clonePlugin(t, upstream, "tag")
+	git(t, tag.Dir(), "checkout", "--quiet", "v0.2")
+	commit := clonePlugin(t, upstream, "commit")
+	git(t, commit.Dir(), "checkout", "--quiet", root)
+
+	plugins := tools.Plugins{}
+	plugins.Add(upstream, "registered", "")
+	unregistered := plugins.Unregistered()
+	if want := []string{"commit", "default", "dev", "tag"}; !reflect.DeepEqual(unregistered, want) {
+		t.Fatalf("Unregistered() = %v, want %v", unregistered, want)
+	}
+
+	for name, version := range map[string]string{
+		"default": "",
+		"dev":     "dev",
+		"tag":     "v0.2",
+		"commit":  root,
+	} {
+		plugin, err := plugins.Adopt(name)
+		if err != nil {
+			t.Errorf("Adopt(%s): %s", name, err)
+			continue
+		}
+		if plugin.URL != upstream || plugin.Version != version || !plugin.IsEnabled() {
+			t.Errorf("Adopt(%s) = %+v, want version %q of %s", name, plugin, version, upstream)
+		}
+	}
+	if len(plugins.Unregistered()) != 0 {
+		t.Errorf("still unregistered: %v", plugins.Unregistered())
+	}
+	if _, err := plugins.Adopt("registered"); err == nil {
+		t.Error("expected an error adopting a registered plugin")
+	}
+}
@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+// ErrNoBisect is returned when no bisect is in progress.
+var ErrNoBisect = errors.New("no bisect in progress")
+
+// ErrBisecting is returned when a command would rewrite the configuration
+// that a bisect is testing.
+var ErrBisecting = errors.New("a bisect is in progress, finish it with vim-bisect good, bad or reset first")
+
+// CheckNoBisect returns ErrBisecting while a bisect is in progress.
+func CheckNoBisect() error {
+	_, err := ReadBisect()
+	if errors.Is(err, ErrNoBisect) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrBisecting
+}
+
+// Bisect is the state of a search for the plugin that breaks the
+// configuration. Suspects are the plugins that may be at fault and Testing
+// those of them enabled in the current step, along with whatever they
+// depend on. All the other plugins in Pool are disabled while bisecting;
+// plugins outside of Pool keep their own enabled state. When the search is
+// over Testing is empty and Suspects holds the culprit.
+type Bisect struct {
+	Pool     []string `json:"pool"`
+	Suspects []string `json:"suspects"`
+	Testing  []string `json:"testing"`
+}
+
+// BisectPath ....
+func BisectPath() string {
+	return filepath.Join(MetadataDir(), "bisect.json")
+}
+
+// ReadBisect returns the bisect in progress, or ErrNoBisect.
+func ReadBisect() (Bisect, error) {
+	b := Bisect{}
+	data, err := afero.ReadFile(Filesys, BisectPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, ErrNoBisect
+	}
+	if err != nil {
+		return b, fmt.Errorf("failed to read bisect state: %w", err)
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("failed to unmarshal bisect json: %w", err)
+	}
+	return b, nil
+}
+
+// Write ....
+func (b Bisect) Write() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("conversion to JSON failed: %w", err)
+	}
+	if err := writeFile(BisectPath(), data); err != nil {
+		return fmt.Errorf("failed to write bisect state: %w", err)
+	}
+	return nil
+}
+
+// RemoveBisect forgets the bisect in progress.
+func RemoveBisect() error {
+	if err := Filesys.Remove(BisectPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove bisect state: %w", err)
+	}
+	return nil
+}
+
+// Done reports whether the culprit has been found.
+func (b Bisect) Done() bool {
+	return len(b.Testing) == 0
+}
+
+// StepsLeft estimates how many more steps the search takes.
+func (b Bisect) StepsLeft() int {
+	steps := 0
+	for n := len(b.Suspects); n > 1; n = (n + 1) / 2 {
+		steps++
+	}
+	return steps
+}
+
+// StartBisect begins a search among the named plugins, or among all the
+// enabled plugins when no names are given.
+func (p Plugins) StartBisect(names []string) (Bisect, error) {
+	if len(names) == 0 {
+		for _, name := range p.SortedNames() {
+			if p[name].IsEnabled() {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		plugin, ok := p[name]
+		if !ok {
+			return Bisect{}, fmt.Errorf("no such plugin %s", name)
+		}
+		if plugin.IsDisabled() {
+			return Bisect{}, fmt.Errorf("plugin %s is disabled", name)
+		}
+	}
+	if len(names) == 0 {
+		return Bisect{}, errors.New("no enabled plugins to bisect")
+	}
+	pool := append([]string{}, names...)
+	sort.Strings(pool)
+	b := Bisect{Pool: pool, Suspects: pool}
+	if err := p.nextBisectStep(&b); err != nil {
+		return Bisect{}, err
+	}
+	return b, nil
+}
+
+// MarkBisect records whether the configuration of the current step is
+// good or bad and moves on to the next step.
+func (p Plugins) MarkBisect(b Bisect, bad bool) (Bisect, error) {
+	if b.Done() {
+		return b, errors.New("bisect is already done")
+	}
+	testing := map[string]bool{}
+	for _, name := range b.Testing {
+		testing[name] = true
+	}
+	suspects := []string{}
+	for _, name := range b.Suspects {
+		if testing[name] == bad {
+			suspects = append(suspects, name)
+		}
+	}
+	b.Suspects = suspects
+	if err := p.nextBisectStep(&b); err != nil {
+		return b, err
+	}
+	return b, nil
+}
+
+// bisectDeps returns what each plugin needs enabled along with it. As well
+// as the plugins it depends on, every colorscheme plugin needs the plugins
+// of the colorschemes in use, so that no step switches colorscheme.
+func (p Plugins) bisectDeps() (map[string][]string, error) {
+	settings, err := ReadColorschemeSettings()
+	if err != nil {
+		return nil, err
+	}
+	inUse := p.ColorschemePlugins(settings)
+	deps := map[string][]string{}
+	for name, plugin := range p {
+		deps[name] = append(deps[name], plugin.Depends...)
+		if plugin.Colorscheme {
+			for _, cs := range inUse {
+				if cs != name {
+					deps[name] = append(deps[name], cs)
+				}
+			}
+		}
+	}
+	return deps, nil
+}
+
+// closure returns the enabled plugins that enabling names brings in.
+func (p Plugins) closure(names []string, deps map[string][]string) map[string]bool {
+	found := map[string]bool{}
+	var visit func(string)
+	visit = func(name string) {
+		if found[name] || !p[name].IsEnabled() {
+			return
+		}
+		found[name] = true
+		for _, dep := range deps[name] {
+			visit(dep)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return found
+}
+
+// nextBisectStep picks the suspects to test next: as close to half of them
+// as their dependencies allow. Suspects that bring in the fewest other
+// suspects are picked first.
+func (p Plugins) nextBisectStep(b *Bisect) error {
+	b.Testing = nil
+	if len(b.Suspects) <= 1 {
+		return nil
+	}
+	deps, err := p.bisectDeps()
+	if err != nil {
+		return err
+	}
+	countSuspects := func(enabled map[string]bool) int {
+		n := 0
+		for _, name := range b.Suspects {
+			if enabled[name] {
+				n++
+			}
+		}
+		return n
+	}
+	order := append([]string{}, b.Suspects...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return countSuspects(p.closure(order[i:i+1], deps)) < countSuspects(p.closure(order[j:j+1], deps))
+	})
+	var chosen []string
+	var best map[string]bool
+	for _, name := range order {
+		enabled := p.closure(append(chosen, name), deps)
+		n := countSuspects(enabled)
+		if n == len(b.Suspects) {
+			continue
+		}
+		chosen, best = append(chosen, name), enabled
+		if n*2 >= len(b.Suspects) {
+			break
+		}
+	}
+	if best == nil {
+		// the suspects all depend on each other and cannot be told apart
+		return nil
+	}
+	for name := range best {
+		b.Testing = append(b.Testing, name)
+	}
+	sort.Strings(b.Testing)
+	return nil
+}
+
+// BisectStep returns the plugins as configured for the current step.
+func (p Plugins) BisectStep(b Bisect) Plugins {
+	step := Plugins{}
+	for name, plugin := range p {
+		step[name] = plugin
+	}
+	testing := map[string]bool{}
+	for _, name := range b.Testing {
+		testing[name] = true
+	}
+	for _, name := range b.Pool {
+		if plugin, ok := step[name]; ok && !testing[name] {
+			step[name] = plugin.Disable()
+		}
+	}
+	// plugins outside of the pool may be needed by those being tested
+	for _, name := range b.Testing {
+		if plugin, ok := step[name]; ok {
+			step[name] = plugin.Enable()
+		}
+	}
+	return step
+}
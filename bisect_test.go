@@ -0,0 +1,107 @@
+package tools_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestBisect(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		plugins[name] = tools.Plugin{Name: name, Enabled: true}
+	}
+	plugins["c"] = tools.Plugin{Name: "c", Enabled: true, Depends: []string{"g"}}
+	plugins["off"] = tools.Plugin{Name: "off", Enabled: false}
+
+	for _, culprit := range []string{"a", "c", "f", "g", "h"} {
+		t.Run(culprit, func(t *testing.T) {
+			b, err := plugins.StartBisect(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			steps := 0
+			for !b.Done() {
+				step := plugins.BisectStep(b)
+				if step["off"].IsEnabled() {
+					t.Fatal("disabled plugin enabled")
+				}
+				if step["c"].IsEnabled() && !step["g"].IsEnabled() {
+					t.Fatal("c enabled without its dependency g")
+				}
+				b, err = plugins.MarkBisect(b, step[culprit].IsEnabled())
+				if err != nil {
+					t.Fatal(err)
+				}
+				steps++
+			}
+			if !reflect.DeepEqual(b.Suspects, []string{culprit}) {
+				t.Errorf("got %v, want %v", b.Suspects, culprit)
+			}
+			if steps > 4 {
+				t.Errorf("took %d steps", steps)
+			}
+		})
+	}
+
+	t.Run("limited to some plugins", func(t *testing.T) {
+		b, err := plugins.StartBisect([]string{"b", "d"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		step := plugins.BisectStep(b)
+		if !step["a"].IsEnabled() || step["b"].IsEnabled() == step["d"].IsEnabled() {
+			t.Errorf("unexpected step %v", step)
+		}
+	})
+
+	t.Run("plugins that need each other", func(t *testing.T) {
+		plugins := tools.Plugins{
+			"x": {Name: "x", Enabled: true, Depends: []string{"y"}},
+			"y": {Name: "y", Enabled: true, Depends: []string{"x"}},
+		}
+		b, err := plugins.StartBisect(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Done() || len(b.Suspects) != 2 {
+			t.Errorf("got %+v, want both suspects and no step", b)
+		}
+	})
+
+	t.Run("disabled plugin", func(t *testing.T) {
+		if _, err := plugins.StartBisect([]string{"off"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestNoCommitWhileBisecting(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{"a": {Name: "a", CleanName: "a", ConfigFile: "a.lua", Enabled: true}}
+	if err := plugins.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (tools.Bisect{Pool: []string{"a"}, Suspects: []string{"a"}, Testing: []string{"a"}}).Write(); err != nil {
+		t.Fatal(err)
+	}
+	changed := tools.Plugins{"a": plugins["a"].Disable()}
+	if err := tools.Begin(plugins).Commit(changed); !errors.Is(err, tools.ErrBisecting) {
+		t.Fatalf("Commit() = %v, want ErrBisecting", err)
+	}
+	if got, err := tools.Read(); err != nil || !got["a"].IsEnabled() {
+		t.Errorf("plugins file changed while bisecting: %v, %v", got, err)
+	}
+
+	if err := tools.RemoveBisect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tools.Begin(plugins).Commit(changed); err != nil {
+		t.Fatal(err)
+	}
+}
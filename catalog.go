@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ErrNoCatalog is returned when no catalog has been ingested yet.
+var ErrNoCatalog = errors.New("no plugin catalog, ingest one with vim-search -update-catalog")
+
+// CatalogEntry is a plugin known to the catalog.
+type CatalogEntry struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// CatalogPath ....
+func CatalogPath() string {
+	return filepath.Join(MetadataDir(), "catalog.json")
+}
+
+// rawCatalogEntry is a catalog entry as found in the wild, where the same
+// thing goes by several names.
+type rawCatalogEntry struct {
+	Name        string          `json:"name"`
+	URL         string          `json:"url"`
+	Repo        string          `json:"repo"`
+	Description string          `json:"description"`
+	Tags        json.RawMessage `json:"tags"`
+	Category    string          `json:"category"`
+	Categories  []string        `json:"categories"`
+}
+
+// ParseCatalog reads a catalog of plugins: either JSON, a list of entries
+// or an object with the list under "plugins", or the Markdown of an
+// awesome-neovim style list, where the headings become tags.
+func ParseCatalog(r io.Reader) ([]CatalogEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return parseJSONCatalog(trimmed)
+	}
+	return parseMarkdownCatalog(data)
+}
+
+func parseJSONCatalog(data []byte) ([]CatalogEntry, error) {
+	raw := []rawCatalogEntry{}
+	if data[0] == '{' {
+		wrapper := struct {
+			Plugins []rawCatalogEntry `json:"plugins"`
+		}{}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catalog json: %w", err)
+		}
+		raw = wrapper.Plugins
+	} else if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal catalog json: %w", err)
+	}
+	entries := []CatalogEntry{}
+	for _, r := range raw {
+		url := r.URL
+		if url == "" {
+			url = expandRepo(r.Repo)
+		}
+		if url == "" {
+			continue
+		}
+		tags := append([]string{}, r.Categories...)
+		if r.Category != "" {
+			tags = append(tags, r.Category)
+		}
+		// tags may be a list or a single comma separated string
+		var list []string
+		var single string
+		if json.Unmarshal(r.Tags, &list) == nil {
+			tags = append(tags, list...)
+		} else if json.Unmarshal(r.Tags, &single) == nil {
+			tags = append(tags, strings.Split(single, ",")...)
+		}
+		entries = append(entries, newCatalogEntry(r.Name, url, r.Description, tags))
+	}
+	return entries, nil
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownEntry   = regexp.MustCompile(`^\s*[-*]\s+\[([^\]]+)\]\((https?://[^)\s]+)\)\s*[-–—:]?\s*(.*)$`)
+)
+
+func parseMarkdownCatalog(data []byte) ([]CatalogEntry, error) {
+	entries := []CatalogEntry{}
+	headings := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			// the top level heading is the list's title, not a tag
+			level := len(m[1]) - 2
+			if level > len(headings) {
+				level = len(headings)
+			}
+			if level < 0 {
+				headings = headings[:0]
+				continue
+			}
+			headings = append(headings[:level], m[2])
+			continue
+		}
+		m := markdownEntry.FindStringSubmatch(line)
+		if m == nil || strings.Count(strings.Trim(strings.SplitN(m[2], "://", 2)[1], "/"), "/") < 2 {
+			// only links to repositories, not to websites
+			continue
+		}
+		entries = append(entries, newCatalogEntry("", m[2], strings.TrimSuffix(m[3], "."), headings))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	return entries, nil
+}
+
+func newCatalogEntry(name, url, description string, tags []string) CatalogEntry {
+	url = normalizeURL(url)
+	if name == "" || strings.Contains(name, "/") {
+		name = nameFromURL(url)
+	}
+	seen := map[string]bool{}
+	cleaned := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return CatalogEntry{Name: name, URL: url, Description: strings.TrimSpace(description), Tags: cleaned}
+}
+
+// WriteCatalog replaces the catalog with entries. Entries for the same
+// repository are merged.
+func WriteCatalog(entries []CatalogEntry) error {
+	merged := []CatalogEntry{}
+	index := map[string]int{}
+	for _, e := range entries {
+		key := repoKey(e.URL)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, e)
+			continue
+		}
+		if merged[i].Description == "" {
+			merged[i].Description = e.Description
+		}
+		merged[i] = newCatalogEntry(merged[i].Name, merged[i].URL, merged[i].Description, append(merged[i].Tags, e.Tags...))
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return strings.ToLower(merged[i].Name) < strings.ToLower(merged[j].Name)
+	})
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("conversion to JSON failed: %w", err)
+	}
+	if err := writeFile(CatalogPath(), data); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	return nil
+}
+
+// ReadCatalog returns the ingested catalog, or ErrNoCatalog.
+func ReadCatalog() ([]CatalogEntry, error) {
+	data, err := afero.ReadFile(Filesys, CatalogPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCatalog
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	entries := []CatalogEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal catalog json: %w", err)
+	}
+	return entries, nil
+}
+
+// repoKey identifies a repository whichever way its URL is written.
+func repoKey(url string) string {
+	if repo := githubRepo(normalizeURL(url)); repo != "" {
+		return strings.ToLower(repo)
+	}
+	return strings.ToLower(normalizeURL(url))
+}
+
+// SearchCatalog returns the entries that match every term in their name,
+// URL, description or tags, best matches first.
+func SearchCatalog(entries []CatalogEntry, terms []string) []CatalogEntry {
+	type scored struct {
+		entry CatalogEntry
+		score int
+	}
+	found := []scored{}
+	for _, e := range entries {
+		total := 0
+		for _, term := range terms {
+			score := matchScore(e, strings.ToLower(term))
+			if score == 0 {
+				total = 0
+				break
+			}
+			total += score
+		}
+		if total > 0 {
+			found = append(found, scored{e, total})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+	results := make([]CatalogEntry, len(found))
+	for i, f := range found {
+		results[i] = f.entry
+	}
+	return results
+}
+
+// matchScore says how well a lower case term matches an entry, or 0 if it
+// does not match at all.
+func matchScore(e CatalogEntry, term string) int {
+	name := strings.ToLower(e.Name)
+	switch {
+	case name == term || normalizeName(name) == normalizeName(term):
+		return 100
+	case strings.Contains(name, term):
+		return 50
+	}
+	for _, tag := range e.Tags {
+		if tag == term {
+			return 30
+		}
+	}
+	for _, tag := range e.Tags {
+		if strings.Contains(tag, term) {
+			return 20
+		}
+	}
+	if strings.Contains(strings.ToLower(e.Description), term) {
+		return 10
+	}
+	if strings.Contains(strings.ToLower(e.URL), term) {
+		return 5
+	}
+	return 0
+}
+
+// Installed returns the name of the plugin installed from the entry's
+// repository, if any.
+func (p Plugins) Installed(e CatalogEntry) (string, bool) {
+	key := repoKey(e.URL)
+	for _, name := range p.SortedNames() {
+		if repoKey(p[name].URL) == key {
+			return name, true
+		}
+	}
+	return "", false
+}
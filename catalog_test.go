@@ -0,0 +1,112 @@
+package tools_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+const markdownCatalog = `# Awesome Neovim
+
+## Contents
+
+- [Colorscheme](#colorscheme)
+
+## Colorscheme
+
+- [folke/tokyonight.nvim](https://github.com/folke/tokyonight.nvim) - A clean, dark and light Neovim theme.
+
+### Lua Colorscheme
+
+- [catppuccin/nvim](https://github.com/catppuccin/nvim) - Soothing pastel theme.
+
+## Fuzzy Finder
+
+- [nvim-telescope/telescope.nvim](https://github.com/nvim-telescope/telescope.nvim/) - Find, filter, preview and pick.
+- [Website](https://example.com) - Not a plugin.
+`
+
+func TestCatalog(t *testing.T) {
+	t.Run("markdown", func(t *testing.T) {
+		entries, err := tools.ParseCatalog(strings.NewReader(markdownCatalog))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []tools.CatalogEntry{
+			{Name: "tokyonight.nvim", URL: "https://github.com/folke/tokyonight.nvim", Description: "A clean, dark and light Neovim theme", Tags: []string{"colorscheme"}},
+			{Name: "nvim", URL: "https://github.com/catppuccin/nvim", Description: "Soothing pastel theme", Tags: []string{"colorscheme", "lua colorscheme"}},
+			{Name: "telescope.nvim", URL: "https://github.com/nvim-telescope/telescope.nvim", Description: "Find, filter, preview and pick", Tags: []string{"fuzzy finder"}},
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Errorf("got %+v, want %+v", entries, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data := `{"plugins": [
+			{"repo": "folke/tokyonight.nvim", "description": "Theme", "tags": ["colorscheme", "Lua"]},
+			{"name": "telescope", "url": "https://github.com/nvim-telescope/telescope.nvim.git", "category": "Fuzzy Finder", "tags": "picker, search"},
+			{"name": "nothing"}
+		]}`
+		entries, err := tools.ParseCatalog(strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []tools.CatalogEntry{
+			{Name: "tokyonight.nvim", URL: "https://github.com/folke/tokyonight.nvim", Description: "Theme", Tags: []string{"colorscheme", "lua"}},
+			{Name: "telescope", URL: "https://github.com/nvim-telescope/telescope.nvim", Tags: []string{"fuzzy finder", "picker", "search"}},
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Errorf("got %+v, want %+v", entries, want)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		prepareEnv(t)
+		if _, err := tools.ReadCatalog(); !errors.Is(err, tools.ErrNoCatalog) {
+			t.Fatalf("ReadCatalog() without a catalog = %v", err)
+		}
+		entries, err := tools.ParseCatalog(strings.NewReader(markdownCatalog))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tools.WriteCatalog(entries); err != nil {
+			t.Fatal(err)
+		}
+		catalog, err := tools.ReadCatalog()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := func(entries []tools.CatalogEntry) []string {
+			found := []string{}
+			for _, e := range entries {
+				found = append(found, e.Name)
+			}
+			return found
+		}
+		for terms, want := range map[string][]string{
+			"telescope":       {"telescope.nvim"},
+			"colorscheme":     {"nvim", "tokyonight.nvim"},
+			"THEME":           {"nvim", "tokyonight.nvim"},
+			"dark theme":      {"tokyonight.nvim"},
+			"lua colorscheme": {"nvim"},
+			"catppuccin":      {"nvim"},
+			"emacs":           {},
+		} {
+			if got := names(tools.SearchCatalog(catalog, strings.Fields(terms))); !reflect.DeepEqual(got, want) {
+				t.Errorf("SearchCatalog(%q) = %v, want %v", terms, got, want)
+			}
+		}
+
+		plugins := tools.Plugins{"telescope": {Name: "telescope", URL: "git@github.com:nvim-telescope/telescope.nvim.git"}}
+		if name, ok := plugins.Installed(catalog[1]); !ok || name != "telescope" {
+			t.Errorf("Installed(%s) = %s, %v", catalog[1].Name, name, ok)
+		}
+		if _, ok := plugins.Installed(catalog[0]); ok {
+			t.Errorf("%s should not be installed", catalog[0].Name)
+		}
+	})
+}
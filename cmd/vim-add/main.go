@@ -5,14 +5,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	tools "github.com/WhoIsSethDaniel/vim-tools"
 )
 
 func main() {
-	var name, version string
+	var name, version, filter string
+	var depth int
+	var full bool
 	flag.StringVar(&name, "n", "", "Name for given URL (only one URL may be specified)")
 	flag.StringVar(&version, "v", "", "Version to freeze on")
+	flag.IntVar(&depth, "depth", 0, "Create a shallow clone with the given depth")
+	flag.StringVar(&filter, "filter", "", "Create a partial clone using the given filter (e.g. blob:none)")
+	flag.BoolVar(&full, "full", false, "Create a full clone, ignoring the global clone options")
+	tools.LockWaitFlag()
 	flag.Parse()
 
 	if flag.NArg() == 0 {
@@ -28,43 +35,73 @@ func main() {
 		os.Exit(1)
 	}
 
+	if full && (depth > 0 || filter != "") {
+		fmt.Fprintf(os.Stderr, "-full may not be used with -depth or -filter\n")
+		os.Exit(1)
+	}
+	var cloneOpts *tools.CloneOptions
+	if full || depth > 0 || filter != "" {
+		cloneOpts = &tools.CloneOptions{Depth: depth, Filter: filter}
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
 	plugins, err := tools.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
 		os.Exit(1)
 	}
 
+	tx := tools.Begin(plugins)
 	for _, arg := range flag.Args() {
 		plugin := plugins.Add(arg, name, version)
+		if cloneOpts != nil {
+			plugin.Clone = cloneOpts
+			plugins[plugin.Name] = plugin
+		}
 		fmt.Print(" - cloning\n")
 
-		_, err := plugin.CloneRepo()
+		_, err := tx.Clone(&plugin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to clone repo: %s", err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Failed to clone repo: %s\n", err)
+			rollback(tx)
 		}
 		if version != "" {
-			_, err = plugin.RunGit("reset", "--hard", plugin.Version)
-			if err != nil {
+			if err := plugin.Checkout(); err != nil {
 				fmt.Fprintf(
 					os.Stderr,
-					"Failed to reset repo for %s to %s: %s",
+					"Failed to reset repo for %s to %s: %s\n",
 					plugin.Name,
 					plugin.Version,
 					err,
 				)
-				os.Exit(1)
+				rollback(tx)
 			}
 		}
+		plugin, err = plugin.MarkAdded(time.Now()).RecordCheckout(time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to find commit of %s: %s\n", plugin.Name, err)
+			rollback(tx)
+		}
+		plugins[plugin.Name] = plugin
 	}
 
 	fmt.Print(" - rewrite files\n")
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s\n", err)
-		os.Exit(1)
+}
+
+// rollback undoes what has been done so far and exits.
+func rollback(tx *tools.Tx) {
+	if err := tx.Rollback(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 	}
+	os.Exit(1)
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = plugins.Unregistered()
+	}
+
+	failed := false
+	for _, arg := range args {
+		plugin, err := plugins.Adopt(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to adopt %s: %s\n", arg, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("ADOPTED %s [%s]", plugin.Name, plugin.URL)
+		if plugin.HasVersion() {
+			fmt.Printf(" [%s]", plugin.Version)
+		}
+		if plugin.IsColorscheme() {
+			fmt.Print(" (colorscheme)")
+		}
+		fmt.Println()
+	}
+
+	fmt.Print(" - rewrite files\n")
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s start [plugin ...]\n", name)
+	fmt.Fprintf(os.Stderr, "       %s good | bad | reset\n", name)
+	fmt.Fprintf(os.Stderr, "       %s run command [arg ...]\n", name)
+	os.Exit(1)
+}
+
+func fail(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
+
+func main() {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fail("Failed to read plugins file: %s", err)
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	if cmd == "start" {
+		if _, err := tools.ReadBisect(); err == nil {
+			fail("A bisect is already in progress, use reset to abandon it")
+		}
+		b, err := plugins.StartBisect(args)
+		if err != nil {
+			fail("%s", err)
+		}
+		step(plugins, b)
+		return
+	}
+
+	b, err := tools.ReadBisect()
+	if err != nil {
+		fail("%s", err)
+	}
+	switch cmd {
+	case "good", "bad":
+		if len(args) > 0 {
+			usage()
+		}
+		b, err = plugins.MarkBisect(b, cmd == "bad")
+		if err != nil {
+			fail("%s", err)
+		}
+		step(plugins, b)
+	case "reset":
+		if len(args) > 0 {
+			usage()
+		}
+		restore(plugins)
+	case "run":
+		if len(args) == 0 {
+			usage()
+		}
+		for !b.Done() {
+			if err := plugins.BisectStep(b).RebuildConfig(); err != nil {
+				fail("Failed to rebuild configuration: %s", err)
+			}
+			bad, err := test(args)
+			if err != nil {
+				fail("Failed to run %s: %s", args[0], err)
+			}
+			fmt.Printf("%s: %s\n", verdict(bad), strings.Join(b.Testing, " "))
+			b, err = plugins.MarkBisect(b, bad)
+			if err != nil {
+				fail("%s", err)
+			}
+		}
+		step(plugins, b)
+	default:
+		usage()
+	}
+}
+
+// step writes the configuration for the current step, or reports the
+// culprit and puts the configuration back when the search is over.
+func step(plugins tools.Plugins, b tools.Bisect) {
+	if b.Done() {
+		if len(b.Suspects) == 1 {
+			fmt.Printf("%s is the cause\n", b.Suspects[0])
+		} else {
+			fmt.Printf("The cause is one of %s, they cannot be enabled separately\n", strings.Join(b.Suspects, ", "))
+		}
+		restore(plugins)
+		return
+	}
+	if err := b.Write(); err != nil {
+		fail("%s", err)
+	}
+	if err := plugins.BisectStep(b).RebuildConfig(); err != nil {
+		fail("Failed to rebuild configuration: %s", err)
+	}
+	fmt.Printf("Bisecting: %d suspects left (roughly %d steps)\n", len(b.Suspects), b.StepsLeft())
+	fmt.Printf("Enabled: %s\n", strings.Join(b.Testing, " "))
+	fmt.Printf("Start nvim, then run %s good or %s bad\n", filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+}
+
+// restore puts back the configuration of the enabled plugins.
+func restore(plugins tools.Plugins) {
+	if err := plugins.RebuildConfig(); err != nil {
+		fail("Failed to rebuild configuration: %s", err)
+	}
+	if err := tools.RemoveBisect(); err != nil {
+		fail("%s", err)
+	}
+}
+
+// test runs the test command. An exit status of zero means the
+// configuration is good, anything else that it is bad.
+func test(args []string) (bool, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return true, nil
+	}
+	return false, err
+}
+
+func verdict(bad bool) string {
+	if bad {
+		return "bad"
+	}
+	return "good"
+}
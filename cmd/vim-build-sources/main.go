@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -8,6 +9,20 @@ import (
 )
 
 func main() {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+	if err := tools.CheckNoBisect(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
 	plugins, err := tools.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read plugin information: %s\n", err)
@@ -1,28 +1,50 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	tools "github.com/WhoIsSethDaniel/vim-tools"
 )
 
 func main() {
 	var hashCheck, showBranch bool
+	var local string
 	flag.BoolVar(&hashCheck, "hash", false, "Check hash of each installed plugin")
 	flag.BoolVar(&showBranch, "b", false, "Show the branch name that is being inspected")
+	flag.StringVar(&local, "local", "skip", "What to do with local modifications: skip, stash or force")
+	tools.LockWaitFlag()
 	flag.Parse()
 
+	policy, err := tools.ParseLocalPolicy(local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+	if err := tools.CheckNoBisect(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
 	plugins, err := tools.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
 		os.Exit(1)
 	}
+	tx := tools.Begin(plugins)
 
 	var args []string
 	if flag.NArg() == 0 {
@@ -39,6 +61,23 @@ func main() {
 	}
 
 	var wg sync.WaitGroup
+	// the commit each clone ends up on, recorded once all are checked
+	var checkedOutMu sync.Mutex
+	checkedOut := map[string]tools.Plugin{}
+	record := func(plugin tools.Plugin, cloned bool) error {
+		now := time.Now()
+		recorded, err := plugin.RecordCheckout(now)
+		if err != nil {
+			return fmt.Errorf("%s: failed to record the checkout: %w", plugin.Name, err)
+		}
+		if cloned {
+			recorded.Updated = now.Format(time.RFC3339)
+		}
+		checkedOutMu.Lock()
+		checkedOut[plugin.Name] = recorded
+		checkedOutMu.Unlock()
+		return nil
+	}
 	toPrint := make(chan string)
 	errPrint := make(chan error)
 	defer close(toPrint)
@@ -60,6 +99,16 @@ func main() {
 		} else {
 			go func(plugin tools.Plugin) {
 				defer wg.Done()
+				// only a clone that is where it should be is recorded
+				cloned, checked := false, false
+				defer func() {
+					if !checked {
+						return
+					}
+					if err := record(plugin, cloned); err != nil {
+						errPrint <- err
+					}
+				}()
 				if _, err := os.Stat(filepath.Join(tools.PluginDir(), plugin.Name)); err != nil {
 					out, err := plugin.CloneRepo()
 					if err != nil {
@@ -67,23 +116,25 @@ func main() {
 						return
 					}
 					if plugin.HasVersion() {
-						if _, err := plugin.RunGit("reset", "--hard", plugin.Version); err != nil {
-							errPrint <- fmt.Errorf("%s: failed to reset repo: %s: %w", plugin.Name, strings.TrimRight(out, "\n"), err)
+						if err := plugin.Checkout(); err != nil {
+							errPrint <- fmt.Errorf("%s: failed to reset repo: %w", plugin.Name, err)
 							return
 						}
 					}
+					cloned, checked = true, true
 					toPrint <- fmt.Sprintf("CLONED %s", plugin.Name)
 					return
 				}
 				var branch, symref string
 				switch plugin.Version {
 				case "":
-					symref, err = plugin.RunGit("symbolic-ref", "HEAD")
+					ref, err := plugin.RunGit("symbolic-ref", "HEAD")
 					if err != nil {
 						errPrint <- err
 						return
 					}
-					branch = path.Base(symref)
+					symref = ref
+					branch = strings.TrimPrefix(symref, "refs/heads/")
 				default:
 					branch = plugin.Version
 				}
@@ -97,6 +148,57 @@ func main() {
 					errPrint <- err
 					return
 				}
+				if symref != "" && !hasRef(rheadRefs, symref) {
+					// the branch is gone upstream, as when upstream renames its
+					// primary branch; a branch that still exists is left alone
+					remoteBranch, err := plugin.DefaultBranch()
+					if err != nil {
+						errPrint <- err
+						return
+					}
+					if remoteBranch == branch {
+						errPrint <- fmt.Errorf("ERROR %s: no remote heads found for %s", plugin.Name, branch)
+						return
+					}
+					err = plugin.ProtectLocalChanges(policy, func() error {
+						return plugin.TrackBranch(remoteBranch)
+					})
+					if errors.Is(err, tools.ErrLocalChanges) {
+						toPrint <- fmt.Sprintf("DIRTY %s [%s -> %s]", plugin.Name, branch, remoteBranch)
+						return
+					}
+					if err != nil {
+						errPrint <- fmt.Errorf("ERROR %s: failed to switch from %s to %s: %w", plugin.Name, branch, remoteBranch, err)
+						return
+					}
+					checked = true
+					toPrint <- fmt.Sprintf("SWITCHED %s [%s -> %s]", plugin.Name, branch, remoteBranch)
+					return
+				}
+				outputString := plugin.Name
+				if showBranch {
+					outputString = fmt.Sprintf("%s [%s]", outputString, branch)
+				}
+				if rheadRefs == "" && plugin.HasVersion() {
+					// frozen to a commit rather than a branch or tag
+					if commit, err := plugin.ResolveRef(plugin.Version); err == nil && lhead == commit {
+						checked = true
+						toPrint <- fmt.Sprintf("OK %s", outputString)
+						return
+					}
+					err := plugin.ProtectLocalChanges(policy, plugin.Checkout)
+					if errors.Is(err, tools.ErrLocalChanges) {
+						toPrint <- fmt.Sprintf("DIRTY %s", outputString)
+						return
+					}
+					if err != nil {
+						errPrint <- fmt.Errorf("ERROR %s: %w", outputString, err)
+						return
+					}
+					checked = true
+					toPrint <- fmt.Sprintf("UPDATED %s", outputString)
+					return
+				}
 				// this logic is not always correct.
 				var rhead string
 				rheads := strings.Split(rheadRefs, "\n")
@@ -119,23 +221,35 @@ func main() {
 					errPrint <- fmt.Errorf("failed to find remote head for %s", plugin.Name)
 					return
 				}
-				outputString := plugin.Name
-				if showBranch {
-					outputString = fmt.Sprintf("%s [%s]", outputString, branch)
-				}
 				if lhead == rhead {
+					checked = true
 					toPrint <- fmt.Sprintf("OK %s", outputString)
-				} else {
+					return
+				}
+				err = plugin.ProtectLocalChanges(policy, func() error {
 					if _, err := plugin.RunGit("pull", "--rebase", plugin.URL, branch); err != nil {
-						errPrint <- fmt.Errorf("ERROR %s", outputString)
+						return err
 					}
 					if plugin.HasVersion() {
 						if _, err := plugin.RunGit("reset", "--hard", branch); err != nil {
-							errPrint <- fmt.Errorf("ERROR %s", outputString)
+							return err
 						}
 					}
-					toPrint <- fmt.Sprintf("UPDATED %s", outputString)
+					if err := plugin.UpdateSubmodules(); err != nil {
+						return fmt.Errorf("failed to update submodules: %w", err)
+					}
+					return nil
+				})
+				if errors.Is(err, tools.ErrLocalChanges) {
+					toPrint <- fmt.Sprintf("DIRTY %s", outputString)
+					return
 				}
+				if err != nil {
+					errPrint <- fmt.Errorf("ERROR %s: %w", outputString, err)
+					return
+				}
+				checked = true
+				toPrint <- fmt.Sprintf("UPDATED %s", outputString)
 			}(plugin)
 		}
 	}
@@ -145,7 +259,7 @@ func main() {
 		// remove plugins that are no longer being used
 		for pluginName, pluginPath := range tools.PluginsOnDisk() {
 			if _, ok := plugins[pluginName]; !ok {
-				fmt.Printf("DELETE %s\n", pluginName)
+				toPrint <- fmt.Sprintf("DELETE %s", pluginName)
 				os.RemoveAll(pluginPath)
 			}
 		}
@@ -161,12 +275,28 @@ done:
 			break done
 		case txt := <-toPrint:
 			fmt.Print(txt + "\n")
+			if !hashCheck && !strings.HasPrefix(txt, "OK ") {
+				tx.Note("%s", txt)
+			}
 		case e := <-errPrint:
 			fmt.Fprintf(os.Stderr, "%s\n", e)
 		}
 	}
 
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s\n", err)
+	for name, plugin := range checkedOut {
+		plugins[name] = plugin
+	}
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+	}
+}
+
+// hasRef reports whether ls-remote output lists ref.
+func hasRef(refs, ref string) bool {
+	for _, line := range strings.Split(refs, "\n") {
+		if f := strings.Fields(line); len(f) == 2 && f[1] == ref {
+			return true
+		}
 	}
+	return false
 }
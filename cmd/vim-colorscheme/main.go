@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var pluginName, from, until string
+	var clear, light, dark bool
+	flag.StringVar(&pluginName, "p", "", "Plugin providing the colorscheme, when more than one does")
+	flag.BoolVar(&clear, "clear", false, "Forget the chosen colorscheme(s)")
+	flag.BoolVar(&light, "light", false, "Use the colorscheme when 'background' is light")
+	flag.BoolVar(&dark, "dark", false, "Use the colorscheme when 'background' is dark")
+	flag.StringVar(&from, "from", "", "With -light or -dark, prefer the colorscheme from this time (HH:MM)")
+	flag.StringVar(&until, "until", "", "With -light or -dark, prefer the colorscheme until this time (HH:MM)")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() > 1 || (clear && flag.NArg() > 0) || (light && dark) {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-light | -dark] [-clear | [-p plugin] [-from HH:MM] [-until HH:MM] colorscheme]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+	if (from != "" || until != "") && !light && !dark {
+		fmt.Fprintf(os.Stderr, "-from and -until need -light or -dark\n")
+		os.Exit(1)
+	}
+	for _, t := range []string{from, until} {
+		if t != "" && !tools.ValidTimeOfDay(t) {
+			fmt.Fprintf(os.Stderr, "Invalid time %q, use HH:MM\n", t)
+			os.Exit(1)
+		}
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	settings, err := tools.ReadColorschemeSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	previous := settings
+	tx := tools.Begin(plugins)
+
+	if flag.NArg() == 0 && !clear {
+		for _, cs := range plugins.Colorschemes() {
+			marker := " "
+			if cs == settings.Active {
+				marker = "*"
+			}
+			fmt.Printf("%s %s [%s]", marker, cs.Scheme, cs.Plugin)
+			if plugins[cs.Plugin].IsDisabled() {
+				fmt.Print(" (disabled)")
+			}
+			for _, bg := range []struct {
+				name string
+				cs   *tools.Colorscheme
+			}{{"light", settings.Light}, {"dark", settings.Dark}} {
+				if bg.cs == nil || bg.cs.Plugin != cs.Plugin || bg.cs.Scheme != cs.Scheme {
+					continue
+				}
+				fmt.Printf(" (%s", bg.name)
+				if bg.cs.From != "" || bg.cs.Until != "" {
+					fmt.Printf(" %s-%s", bg.cs.From, bg.cs.Until)
+				}
+				fmt.Print(")")
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	if clear {
+		switch {
+		case light:
+			settings.Light = nil
+		case dark:
+			settings.Dark = nil
+		default:
+			settings = tools.ColorschemeSettings{}
+		}
+	} else {
+		found := plugins.FindColorscheme(flag.Arg(0), pluginName)
+		switch len(found) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "Cannot find colorscheme %s\n", flag.Arg(0))
+			os.Exit(1)
+		case 1:
+		default:
+			fmt.Fprintf(os.Stderr, "Colorscheme %s is provided by more than one plugin, use -p to choose one of:\n", flag.Arg(0))
+			for _, cs := range found {
+				fmt.Fprintf(os.Stderr, "  %s\n", cs.Plugin)
+			}
+			os.Exit(1)
+		}
+		chosen := found[0]
+		chosen.From, chosen.Until = from, until
+		switch {
+		case light:
+			settings.Light = &chosen
+		case dark:
+			settings.Dark = &chosen
+		default:
+			settings.Active = chosen
+		}
+		plugin := plugins[found[0].Plugin].Enable()
+		plugin.Colorscheme = true
+		plugins[plugin.Name] = plugin
+	}
+
+	tx.Note("COLORSCHEME %s", describe(settings))
+	if err := settings.Write(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	tx.OnRollback(previous.Write)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// describe summarises the chosen colorschemes for the journal.
+func describe(settings tools.ColorschemeSettings) string {
+	parts := []string{}
+	if settings.Active.Scheme != "" {
+		parts = append(parts, fmt.Sprintf("%s [%s]", settings.Active.Scheme, settings.Active.Plugin))
+	}
+	for _, bg := range []struct {
+		name string
+		cs   *tools.Colorscheme
+	}{{"light", settings.Light}, {"dark", settings.Dark}} {
+		if bg.cs != nil {
+			parts = append(parts, fmt.Sprintf("%s: %s [%s]", bg.name, bg.cs.Scheme, bg.cs.Plugin))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,32 +1,184 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	tools "github.com/WhoIsSethDaniel/vim-tools"
 )
 
+// ask asks a yes/no question, defaulting to no.
+func ask(r *bufio.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := r.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// askSnippets offers the README setup snippet to each of the named plugins
+// that has no config file of the given kind yet, and returns the snippets
+// that were accepted. It is called before taking the lock so that an
+// unanswered question does not hold up every other command.
+func askSnippets(names []string, kind string) map[string]string {
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	snippets := map[string]string{}
+	for _, name := range names {
+		plugin, ok := plugins[name]
+		if !ok {
+			continue
+		}
+		if kind != "" {
+			plugin = plugin.WithConfigKind(kind)
+		}
+		// README snippets are Lua
+		if !luaConfig(plugin) {
+			continue
+		}
+		if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
+			continue
+		}
+		// a plugin that is not installed has no README to look at
+		snippet, _ := plugin.ReadmeSetupSnippet()
+		if snippet != "" && ask(stdin, fmt.Sprintf("%s\nUse this setup snippet from the README of %s?", snippet, plugin.Name)) {
+			snippets[name] = snippet
+		}
+	}
+	return snippets
+}
+
+// luaConfig reports whether a plugin's config is written in Lua.
+func luaConfig(plugin tools.Plugin) bool {
+	return plugin.ConfigKind() == tools.ConfigLua || plugin.ConfigKind() == tools.ConfigDir
+}
+
+// cleanUnused reattaches unused config files to plugins and archives
+// whatever is left over.
+func cleanUnused(tx *tools.Tx, plugins tools.Plugins, prune, reattach, dryRun bool) {
+	unused := plugins.UnusedConfigFiles()
+	sort.Strings(unused)
+
+	if reattach {
+		remaining := []string{}
+		candidates := plugins.PluginsWithoutConfig()
+		for _, cf := range unused {
+			name, ok := tools.FuzzyMatch(cf, candidates)
+			if !ok {
+				remaining = append(remaining, cf)
+				continue
+			}
+			fmt.Printf("REATTACH %s -> %s\n", cf, name)
+			if !dryRun {
+				if _, err := plugins.ReattachConfig(tx, cf, name); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reattach config: %s\n", err)
+					if err := tx.Rollback(); err != nil {
+						fmt.Fprintf(os.Stderr, "%s\n", err)
+					}
+					os.Exit(1)
+				}
+			}
+			for i := range candidates {
+				if candidates[i] == name {
+					candidates = append(candidates[:i], candidates[i+1:]...)
+					break
+				}
+			}
+		}
+		unused = remaining
+	}
+
+	if prune && len(unused) > 0 {
+		for _, cf := range unused {
+			fmt.Printf("ARCHIVE %s\n", cf)
+		}
+		if !dryRun {
+			dir, err := tx.ArchiveConfigs(unused, time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				if err := tx.Rollback(); err != nil {
+					fmt.Fprintf(os.Stderr, "%s\n", err)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf(" - archived to %s\n", dir)
+			for _, cf := range unused {
+				tx.Note("ARCHIVE %s -> %s", cf, dir)
+			}
+		}
+	}
+
+	if dryRun {
+		return
+	}
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
 func main() {
-	var create, edit bool
+	var create, edit, prune, reattach, dryRun bool
+	var kind string
 	flag.BoolVar(&create, "c", false, "Create the config file for the given plugin(s)")
 	flag.BoolVar(&edit, "e", false, "Edit the config file(s) for the given plugin(s)")
+	flag.StringVar(&kind, "t", "", "Type of config to create: lua, vim or dir (default: keep the current type)")
+	flag.BoolVar(&prune, "prune", false, "Move unused config files into the config archive")
+	flag.BoolVar(&reattach, "reattach", false, "Give unused config files to plugins with a similar name and no config")
+	flag.BoolVar(&dryRun, "n", false, "With -prune or -reattach only show what would be done")
+	tools.LockWaitFlag()
 	flag.Parse()
 
-	if flag.NArg() == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-ce] plugin [plugin ...]\n", filepath.Base(os.Args[0]))
+	if flag.NArg() == 0 && !prune && !reattach {
+		fmt.Fprintf(
+			os.Stderr,
+			"Usage: %s [-ce] [-t lua|vim|dir] plugin [plugin ...]\n       %s [-n] [-prune] [-reattach]\n",
+			filepath.Base(os.Args[0]),
+			filepath.Base(os.Args[0]),
+		)
+		os.Exit(1)
+	}
+	switch kind {
+	case "", tools.ConfigLua, tools.ConfigVim, tools.ConfigDir:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config type %s\n", kind)
+		os.Exit(1)
+	}
+
+	var snippets map[string]string
+	if create && !prune && !reattach {
+		snippets = askSnippets(flag.Args(), kind)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
+	defer lock.Release()
 
 	plugins, err := tools.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
 		os.Exit(1)
 	}
+	tx := tools.Begin(plugins)
+
+	if prune || reattach {
+		cleanUnused(tx, plugins, prune, reattach, dryRun)
+		return
+	}
 
 	var configs []string
 	for _, arg := range flag.Args() {
@@ -37,28 +189,40 @@ func main() {
 		}
 
 		if create {
+			if kind != "" {
+				plugin = plugin.WithConfigKind(kind)
+				plugins[arg] = plugin
+			}
 			if _, err := os.Stat(plugin.ConfigFilePath()); err != nil {
-				f, err := os.Create(plugin.ConfigFilePath())
-				f.Close()
+				content, err := plugin.RenderConfig()
 				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to render config template: %s\n", err)
+					os.Exit(1)
+				}
+				if snippet, ok := snippets[arg]; ok && luaConfig(plugin) {
+					content = snippet
+				}
+				if plugin.ConfigKind() == tools.ConfigDir {
+					if err := os.MkdirAll(plugin.ConfigFilePath(), 0o755); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to create config directory: %s\n", err)
+						os.Exit(1)
+					}
+				}
+				if err := os.WriteFile(plugin.ConfigEditPath(), []byte(content), 0o644); err != nil {
 					fmt.Fprintf(os.Stderr, "Failed to create config file: %s\n", err)
 					os.Exit(1)
 				}
 			}
 		}
-		configs = append(configs, plugin.ConfigFilePath())
+		configs = append(configs, plugin.ConfigEditPath())
 	}
 
 	if len(configs) == 0 {
 		os.Exit(1)
 	}
 
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
-		os.Exit(1)
-	}
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s\n", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
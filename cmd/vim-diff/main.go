@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var fetch bool
+	flag.BoolVar(&fetch, "f", false, "Fetch from the plugin URL before looking for local commits")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-f] plugin [plugin ...]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	for _, arg := range flag.Args() {
+		plugin, ok := plugins[arg]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "No such plugin %s\n", arg)
+			os.Exit(1)
+		}
+		if fetch {
+			if err := plugin.Fetch(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		diff, patches, err := plugin.LocalDiff()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to find local modifications: %s\n", err)
+			os.Exit(1)
+		}
+		if diff == "" && patches == "" {
+			continue
+		}
+		fmt.Printf("# %s\n", plugin.Name)
+		if diff != "" {
+			fmt.Printf("## uncommitted changes\n%s\n", diff)
+		}
+		if patches != "" {
+			fmt.Printf("## local commits\n%s\n", patches)
+		}
+	}
+}
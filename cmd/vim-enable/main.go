@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,12 +10,27 @@ import (
 )
 
 func main() {
-	plugins, _ := tools.Read()
-	if len(os.Args) <= 1 {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+	if flag.NArg() == 0 {
 		fmt.Fprintf(os.Stderr, "Usage: %s plugin [plugin ...]\n", filepath.Base(os.Args[0]))
 		os.Exit(1)
 	}
-	for _, arg := range os.Args[1:] {
+	for _, arg := range flag.Args() {
 		plugin, ok := plugins[arg]
 		switch {
 		case !ok:
@@ -32,13 +48,8 @@ func main() {
 		}
 	}
 
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
-		os.Exit(1)
-	}
-
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var format, output string
+	flag.StringVar(&format, "format", "", "Format to export to: lazy, packer or plug")
+	flag.StringVar(&output, "o", "", "File to write to instead of standard output")
+	flag.Parse()
+
+	if format == "" || flag.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s --format <lazy|packer|plug> [-o file]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if output != "" {
+		out, err = os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %s\n", output, err)
+			os.Exit(1)
+		}
+	}
+	if err := plugins.Export(format, out); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export plugins: %s\n", err)
+		os.Exit(1)
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %s\n", output, err)
+		os.Exit(1)
+	}
+}
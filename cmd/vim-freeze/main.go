@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -10,38 +11,90 @@ import (
 )
 
 func main() {
-	var version string
+	var version, local string
+	var current bool
 	flag.StringVar(&version, "v", "", "Freeze to a particular branch/tag")
+	flag.BoolVar(&current, "current", false, "Freeze to the commit that is currently checked out")
+	flag.StringVar(&local, "local", "skip", "What to do with local modifications: skip, stash or force")
+	tools.LockWaitFlag()
 	flag.Parse()
 
-	plugins, _ := tools.Read()
-	if version == "" || flag.NArg() == 0 {
-		if version == "" {
-			fmt.Fprint(os.Stderr, "-v is required\n")
+	policy, err := tools.ParseLocalPolicy(local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+	if (version == "") == !current || flag.NArg() == 0 {
+		if version == "" && !current {
+			fmt.Fprint(os.Stderr, "-v or --current is required\n")
+		}
+		if version != "" && current {
+			fmt.Fprint(os.Stderr, "-v and --current may not be used together\n")
 		}
 		fmt.Fprintf(
 			os.Stderr,
-			"Usage: %s -v <branch/tag> plugin [plugin ...]\n",
+			"Usage: %s [-v <branch/tag> | --current] plugin [plugin ...]\n",
 			filepath.Base(os.Args[0]),
 		)
 		os.Exit(1)
 	}
+
+	failed := false
 	for _, arg := range flag.Args() {
 		plugin, ok := plugins[arg]
 		if !ok {
 			fmt.Fprintf(os.Stderr, "cannot find %s\n", arg)
-		} else {
-			plugins[arg] = plugin.Freeze(version)
+			continue
+		}
+		freezeTo := version
+		if current {
+			commit, err := plugin.CurrentCommit()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to find current commit: %s\n", err)
+				failed = true
+				continue
+			}
+			freezeTo = commit
+		}
+
+		plugins[arg] = plugin.Freeze(freezeTo)
+		frozen := plugins[arg]
+		err := frozen.ProtectLocalChanges(policy, frozen.Checkout)
+		if errors.Is(err, tools.ErrLocalChanges) {
+			fmt.Fprintf(os.Stderr, "Not freezing %s: %s, use -local stash or force\n", arg, err)
+			plugins[arg] = plugin
+			failed = true
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to check out %s, not freezing %s: %s\n", freezeTo, arg, err)
+			plugins[arg] = plugin
+			failed = true
+			continue
 		}
+		fmt.Printf("FROZEN %s [%s]\n", arg, freezeTo)
 	}
 
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s", err)
+	if failed {
 		os.Exit(1)
 	}
 }
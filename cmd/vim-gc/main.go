@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var reportOnly, aggressive bool
+	var jobs int
+	var largeMiB int64
+	flag.BoolVar(&reportOnly, "n", false, "Only report disk usage, do not run git gc")
+	flag.BoolVar(&aggressive, "aggressive", false, "Run git gc --aggressive, which is slower but packs tighter")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "Number of repos to work on at once")
+	flag.Int64Var(&largeMiB, "large", 0, "Flag repos larger than this many MiB (default: four times the median)")
+	tools.LockWaitFlag()
+	flag.Parse()
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	// gc must not race vim-check pulling into the same repos, and the sizes
+	// before it must not include what vim-check changes in the meantime
+	if !reportOnly {
+		lock, err := tools.LockPlugins()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		defer lock.Release()
+	}
+
+	onDisk := tools.PluginsOnDisk()
+	var names []string
+	if flag.NArg() == 0 {
+		for name := range onDisk {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else {
+		names = flag.Args()
+		for _, name := range names {
+			if _, ok := onDisk[name]; !ok {
+				fmt.Fprintf(os.Stderr, "No such plugin on disk %s\n", name)
+				os.Exit(1)
+			}
+		}
+	}
+
+	before := measure(names, onDisk, jobs)
+	report(before, tools.LargeRepos(before, largeMiB*1024*1024))
+	if reportOnly {
+		return
+	}
+
+	fmt.Print(" - git gc\n")
+	errs := make([]error, len(before))
+	parallel(len(before), jobs, func(i int) {
+		plugin := tools.Plugin{Name: before[i].Name}
+		errs[i] = plugin.GC(aggressive)
+	})
+	gced := []string{}
+	for i, u := range before {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "ERROR %s: %s\n", u.Name, errs[i])
+			continue
+		}
+		gced = append(gced, u.Name)
+	}
+
+	after := map[string]tools.Usage{}
+	for _, u := range measure(gced, onDisk, jobs) {
+		after[u.Name] = u
+	}
+	var reclaimed int64
+	for _, u := range before {
+		a, ok := after[u.Name]
+		if !ok {
+			continue
+		}
+		saved := u.Total() - a.Total()
+		reclaimed += saved
+		if saved != 0 {
+			fmt.Printf("GC %s %s -> %s\n", u.Name, tools.HumanSize(u.Total()), tools.HumanSize(a.Total()))
+		}
+	}
+	if reclaimed < 0 {
+		fmt.Printf("Used %s more\n", tools.HumanSize(-reclaimed))
+	} else {
+		fmt.Printf("Reclaimed %s\n", tools.HumanSize(reclaimed))
+	}
+	if len(gced) < len(before) {
+		os.Exit(1)
+	}
+}
+
+// measure returns the disk usage of the named repos, largest first. Those
+// that cannot be measured are reported and left out.
+func measure(names []string, onDisk map[string]string, jobs int) []tools.Usage {
+	usages := make([]tools.Usage, len(names))
+	errs := make([]error, len(names))
+	parallel(len(names), jobs, func(i int) {
+		usages[i], errs[i] = tools.RepoUsage(names[i], onDisk[names[i]])
+	})
+	measured := []tools.Usage{}
+	for i, u := range usages {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", errs[i])
+			continue
+		}
+		measured = append(measured, u)
+	}
+	sort.SliceStable(measured, func(i, j int) bool {
+		return measured[i].Total() > measured[j].Total()
+	})
+	return measured
+}
+
+// parallel calls f for 0..n-1, at most jobs at a time.
+func parallel(n, jobs int, f func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func report(usages []tools.Usage, large map[string]bool) {
+	width := len("PLUGIN")
+	for _, u := range usages {
+		if len(u.Name) > width {
+			width = len(u.Name)
+		}
+	}
+	line := func(name, git, worktree, total, flag string) {
+		fmt.Println(strings.TrimRight(fmt.Sprintf("%-*s %10s %10s %10s %s", width, name, git, worktree, total, flag), " "))
+	}
+	line("PLUGIN", ".git", "WORKTREE", "TOTAL", "")
+	var sum tools.Usage
+	for _, u := range usages {
+		flag := ""
+		if large[u.Name] {
+			flag = "LARGE"
+		}
+		line(u.Name, tools.HumanSize(u.Git), tools.HumanSize(u.Worktree), tools.HumanSize(u.Total()), flag)
+		sum.Git += u.Git
+		sum.Worktree += u.Worktree
+	}
+	line("TOTAL", tools.HumanSize(sum.Git), tools.HumanSize(sum.Worktree), tools.HumanSize(sum.Total()), "")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var format, lockFile string
+	var clone bool
+	flag.StringVar(&format, "f", "", "Format of the file to import: lazy, packer or plug")
+	flag.StringVar(&lockFile, "l", "", "lazy-lock.json file used to freeze plugins to their locked commit")
+	flag.BoolVar(&clone, "c", false, "Clone the imported plugins")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if format == "" || flag.NArg() == 0 {
+		fmt.Fprintf(
+			os.Stderr,
+			"Usage: %s -f <lazy|packer|plug> [-l lazy-lock.json] [-c] file [file ...]\n",
+			filepath.Base(os.Args[0]),
+		)
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+
+	lockedCommits := map[string]string{}
+	if lockFile != "" {
+		f, err := os.Open(lockFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open lock file: %s\n", err)
+			os.Exit(1)
+		}
+		lockedCommits, err = tools.ParseLazyLock(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	imported := []tools.ImportedPlugin{}
+	for _, arg := range flag.Args() {
+		f, err := os.Open(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %s\n", arg, err)
+			os.Exit(1)
+		}
+		ips, err := tools.ParseImport(format, f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import %s: %s\n", arg, err)
+			os.Exit(1)
+		}
+		imported = append(imported, ips...)
+	}
+	tools.LockImported(imported, lockedCommits)
+
+	failed := false
+	for _, plugin := range plugins.Import(imported) {
+		fmt.Printf("IMPORTED %s\n", plugin.Name)
+		if !clone {
+			continue
+		}
+		// a failed clone is left for vim-check to retry
+		if _, err := plugin.CloneRepo(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to clone repo: %s\n", err)
+			failed = true
+			continue
+		}
+		if plugin.HasVersion() {
+			if err := plugin.Checkout(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to reset repo for %s to %s: %s\n", plugin.Name, plugin.Version, err)
+				failed = true
+			}
+		}
+	}
+
+	fmt.Print(" - rewrite files\n")
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var description, homepage, notes, depends string
+	flag.StringVar(&description, "d", "", "Set the description")
+	flag.StringVar(&homepage, "homepage", "", "Set the homepage")
+	flag.StringVar(&notes, "notes", "", "Set the notes")
+	flag.StringVar(&depends, "depends", "", "Set the comma separated plugins this one depends on")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-d description] [-homepage url] [-notes text] [-depends a,b] plugin\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		// -wait only says how long to wait if anything is to be changed
+		if f.Name != "wait" {
+			set[f.Name] = true
+		}
+	})
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	plugin, ok := plugins[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No such plugin %s\n", flag.Arg(0))
+		os.Exit(1)
+	}
+
+	if len(set) > 0 {
+		lock, err := tools.LockPlugins()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		defer lock.Release()
+
+		// read again now that no one else can change the plugins
+		plugins, err = tools.Read()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+			os.Exit(1)
+		}
+		tx := tools.Begin(plugins)
+		plugin = plugins[plugin.Name]
+		if set["d"] {
+			plugin.Description = description
+		}
+		if set["homepage"] {
+			plugin.Homepage = homepage
+		}
+		if set["notes"] {
+			plugin.Notes = notes
+		}
+		if set["depends"] {
+			plugin.Depends = nil
+			for _, dep := range strings.Split(depends, ",") {
+				dep = strings.TrimSpace(dep)
+				if dep == "" {
+					continue
+				}
+				if _, ok := plugins[dep]; !ok || dep == plugin.Name {
+					fmt.Fprintf(os.Stderr, "Cannot depend on %s\n", dep)
+					os.Exit(1)
+				}
+				plugin.Depends = append(plugin.Depends, dep)
+			}
+		}
+		plugins[plugin.Name] = plugin
+		if err := tx.Commit(plugins); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	show("Name", plugin.Name)
+	show("URL", plugin.URL)
+	show("Description", plugin.Description)
+	show("Homepage", plugin.HomepageURL())
+	show("Notes", plugin.Notes)
+	show("Depends", strings.Join(plugin.Depends, ", "))
+	show("Added", plugin.Added)
+	if updated, err := time.Parse(time.RFC3339, plugin.Updated); err == nil {
+		show("Updated", updated.Local().Format("2006-01-02 15:04:05"))
+	}
+	show("Commit", plugin.Commit)
+
+	dir := plugin.Dir()
+	if size, err := tools.DiskUsage(dir); err != nil {
+		show("Path", dir+" (not installed)")
+	} else {
+		show("Path", fmt.Sprintf("%s (%s)", dir, tools.HumanSize(size)))
+		branch, head, date, err := plugin.HeadInfo()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read HEAD: %s\n", err)
+		} else {
+			if branch == "" {
+				branch = "(detached)"
+			}
+			show("Branch", branch)
+			show("HEAD", fmt.Sprintf("%s %s", head, date))
+		}
+	}
+
+	if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
+		show("Config", plugin.ConfigFilePath())
+	} else {
+		show("Config", plugin.ConfigFilePath()+" (missing)")
+	}
+	show("Colorscheme", yesNo(plugin.Colorscheme))
+	show("Enabled", yesNo(plugin.IsEnabled()))
+	if plugin.HasVersion() {
+		show("Frozen", plugin.Version)
+	} else {
+		show("Frozen", "no")
+	}
+}
+
+func show(label, value string) {
+	if value != "" {
+		fmt.Printf("%-12s %s\n", label+":", value)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
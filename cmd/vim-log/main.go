@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var count int
+	flag.IntVar(&count, "n", 0, "Only show the n most recent entries")
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-n count] [plugin]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	entries, err := tools.ReadJournal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	undoneBy := map[int]int{}
+	for _, entry := range entries {
+		for _, id := range entry.Undoes {
+			undoneBy[id] = entry.ID
+		}
+	}
+
+	shown := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if flag.NArg() == 1 && !mentions(entry, flag.Arg(0)) {
+			continue
+		}
+		if count > 0 && shown == count {
+			break
+		}
+		shown++
+
+		fmt.Printf("#%d  %s  %s\n", entry.ID, entry.Time.Local().Format("2006-01-02 15:04:05"), entry.CommandLine())
+		for _, c := range entry.Changes {
+			switch {
+			case c.Before == nil:
+				fmt.Printf("    + %s (%s)\n", c.Name, c.After.URL)
+			case c.After == nil:
+				fmt.Printf("    - %s\n", c.Name)
+			default:
+				for _, f := range c.Fields() {
+					fmt.Printf("    %s: %s %s -> %s\n", c.Name, f.Field, orNone(f.Before), orNone(f.After))
+				}
+			}
+		}
+		for _, note := range entry.Notes {
+			fmt.Printf("    %s\n", note)
+		}
+		for _, id := range entry.Undoes {
+			fmt.Printf("    undoes #%d\n", id)
+		}
+		if by, ok := undoneBy[entry.ID]; ok {
+			fmt.Printf("    (undone by #%d)\n", by)
+		}
+	}
+}
+
+// mentions reports whether an entry changed, or noted, the plugin.
+func mentions(entry tools.JournalEntry, name string) bool {
+	for _, c := range entry.Changes {
+		if c.Name == name {
+			return true
+		}
+	}
+	for _, note := range entry.Notes {
+		for _, word := range strings.Fields(note) {
+			if word == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var onlyOutdated bool
+	flag.BoolVar(&onlyOutdated, "o", false, "Only show plugins that are behind upstream")
+	flag.Parse()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	var args []string
+	if flag.NArg() == 0 {
+		for _, name := range plugins.SortedNames() {
+			if plugins[name].HasVersion() {
+				args = append(args, name)
+			}
+		}
+	} else {
+		args = flag.Args()
+		for _, arg := range args {
+			plugin, ok := plugins[arg]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "No such plugin %s\n", arg)
+				os.Exit(1)
+			}
+			if !plugin.HasVersion() {
+				fmt.Fprintf(os.Stderr, "Plugin %s is not frozen\n", arg)
+				os.Exit(1)
+			}
+		}
+	}
+
+	statuses := make([]tools.PinStatus, len(args))
+	errs := make([]error, len(args))
+	var wg sync.WaitGroup
+	for i, pluginName := range args {
+		wg.Add(1)
+		go func(i int, plugin tools.Plugin) {
+			defer wg.Done()
+			statuses[i], errs[i] = plugin.PinStatus()
+		}(i, plugins[pluginName])
+	}
+	wg.Wait()
+
+	for i, pluginName := range args {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "ERROR %s\n", errs[i])
+			continue
+		}
+		status := statuses[i]
+		if onlyOutdated && !status.IsOutdated() {
+			continue
+		}
+		state := "OK"
+		if status.IsOutdated() {
+			state = "OUTDATED"
+		}
+		latestTag := status.LatestTag
+		if latestTag == "" {
+			latestTag = "-"
+		}
+		behind := strconv.Itoa(status.Behind)
+		if status.Behind == tools.BehindUnknown {
+			behind = "unknown (shallow clone)"
+		}
+		fmt.Printf(
+			"%s %s [%s]\n  frozen: %s (%s)\n  latest tag: %s\n  upstream: %s (%s)\n  behind: %s\n",
+			state,
+			pluginName,
+			status.Version,
+			status.Commit,
+			status.CommitDate,
+			latestTag,
+			status.Head,
+			status.HeadDate,
+			behind,
+		)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var on, off, run bool
+	var top int
+	flag.BoolVar(&on, "on", false, "Time each config file at startup (use -run to time the plugins)")
+	flag.BoolVar(&off, "off", false, "Stop timing config files at startup")
+	flag.BoolVar(&run, "run", false, "Run nvim --startuptime instead of reading the recorded timings")
+	flag.IntVar(&top, "n", 0, "Only show the n most expensive entries")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() > 0 || (on && off) {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-on | -off] [-run] [-n count]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	if on || off {
+		if err := tools.CheckNoBisect(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		config, err := tools.ReadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		config.Profile = on
+		if err := config.Write(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		if err := plugins.RebuildConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s\n", err)
+			os.Exit(1)
+		}
+		if !run {
+			return
+		}
+	}
+
+	var entries []tools.ProfileEntry
+	if run {
+		entries, err = startupTime(plugins)
+	} else {
+		entries, err = tools.ReadProfile()
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No timings have been recorded, use -on and start nvim first\n")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var total time.Duration
+	for _, e := range entries {
+		total += e.Duration
+	}
+	if top > 0 && top < len(entries) {
+		entries = entries[:top]
+	}
+	for _, e := range entries {
+		fmt.Printf("%9.3fms  %-8s %s\n", float64(e.Duration)/float64(time.Millisecond), e.Kind, e.Name)
+	}
+	fmt.Printf("%9.3fms  total\n", float64(total)/float64(time.Millisecond))
+}
+
+// startupTime starts nvim and reports where its startup time went.
+func startupTime(plugins tools.Plugins) ([]tools.ProfileEntry, error) {
+	log, err := os.CreateTemp("", "vim-profile")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create startup time log: %w", err)
+	}
+	defer os.Remove(log.Name())
+	defer log.Close()
+
+	cmd := exec.Command("nvim", "--headless", "--startuptime", log.Name(), "+qa")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("nvim failed: %w: %s", err, out)
+	}
+	return plugins.ParseStartupTime(log)
+}
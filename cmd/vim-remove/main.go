@@ -13,6 +13,7 @@ func main() {
 	var keep, noRemove bool
 	flag.BoolVar(&keep, "k", false, "Remove the directory but keep the plugin registered")
 	flag.BoolVar(&noRemove, "u", false, "Do not remove the directory but unregister the plugin")
+	tools.LockWaitFlag()
 	flag.Parse()
 
 	if flag.NArg() == 0 {
@@ -20,34 +21,42 @@ func main() {
 		os.Exit(1)
 	}
 
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
 	plugins, err := tools.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
 		os.Exit(1)
 	}
 
+	tx := tools.Begin(plugins)
 	for _, arg := range flag.Args() {
 		plugin, ok := plugins[arg]
 		if !ok {
 			fmt.Fprintf(os.Stderr, "No such plugin: %s\n", arg)
-			os.Exit(1)
+			rollback(tx)
 		}
 		if !noRemove {
 			pluginDir := filepath.Join(tools.PluginDir(), arg)
 			fi, err := os.Stat(pluginDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "plugin '%s' directory does not exist: %s\n", arg, err)
-				os.Exit(1)
+				rollback(tx)
 			}
 			if !fi.IsDir() {
 				fmt.Fprintf(os.Stderr, "Plugin '%s' has no directory: %s\n", arg, err)
-				os.Exit(1)
+				rollback(tx)
 			}
 
 			fmt.Print(" - removing directory\n")
-			if err := os.RemoveAll(pluginDir); err != nil {
+			if err := tx.RemoveAll(pluginDir); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to remove plugin %s dir: %s\n", arg, err)
-				os.Exit(1)
+				rollback(tx)
 			}
 		}
 
@@ -57,13 +66,16 @@ func main() {
 	}
 
 	fmt.Print(" - rewrite files\n")
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
+}
 
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s\n", err)
-		os.Exit(1)
+// rollback undoes what has been done so far and exits.
+func rollback(tx *tools.Tx) {
+	if err := tx.Rollback(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 	}
+	os.Exit(1)
 }
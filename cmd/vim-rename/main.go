@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,40 +10,61 @@ import (
 )
 
 func main() {
-	plugins, _ := tools.Read()
-	if len(os.Args) <= 2 {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	if flag.NArg() != 2 {
 		fmt.Fprintf(os.Stderr, "Usage: %s name new-name\n", filepath.Base(os.Args[0]))
 		os.Exit(1)
 	}
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
 
 	fmt.Printf(" - rename plugin\n")
-	name := os.Args[1]
-	newName := os.Args[2]
+	name := flag.Arg(0)
+	newName := flag.Arg(1)
 	plugin, ok := plugins[name]
 	if !ok {
-		fmt.Fprintf(os.Stderr, "Cannot find plugin %s", name)
+		fmt.Fprintf(os.Stderr, "Cannot find plugin %s\n", name)
+		os.Exit(1)
+	}
+	if _, ok := plugins[newName]; ok {
+		fmt.Fprintf(os.Stderr, "Plugin %s already exists\n", newName)
+		os.Exit(1)
 	}
+	tx := tools.Begin(plugins)
 	oldConfig := plugin.ConfigFilePath()
-	plugins.Add(plugin.URL, newName, "")
-	delete(plugins, name)
+	renamed := plugins.Rename(name, newName)
 
 	fmt.Print(" - rename plugin dir\n")
-	if err := os.Rename(filepath.Join(tools.PluginDir(), name), filepath.Join(tools.PluginDir(), newName)); err != nil {
+	if err := tx.Rename(filepath.Join(tools.PluginDir(), name), filepath.Join(tools.PluginDir(), newName)); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to rename directory: %s\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Print(" - rename config file\n")
-	// for now don't worry about this failing
-	os.Rename(oldConfig, plugins[newName].ConfigFilePath())
-
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
-		os.Exit(1)
+	if _, err := os.Stat(oldConfig); err == nil {
+		fmt.Print(" - rename config file\n")
+		if err := tx.Rename(oldConfig, renamed.ConfigFilePath()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rename config file: %s\n", err)
+			if err := tx.Rollback(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+			}
+			os.Exit(1)
+		}
 	}
 
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var update string
+	var limit int
+	flag.StringVar(&update, "update-catalog", "", "Replace the catalog with the given JSON or Markdown file")
+	flag.IntVar(&limit, "n", 20, "Show at most this many results (0 for all)")
+	flag.Parse()
+
+	if update == "" && flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-n count] <term> [term ...] | -update-catalog <file>\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	if update != "" {
+		f, err := os.Open(update)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open catalog: %s\n", err)
+			os.Exit(1)
+		}
+		entries, err := tools.ParseCatalog(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(os.Stderr, "No plugins found in %s\n", update)
+			os.Exit(1)
+		}
+		if err := tools.WriteCatalog(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Catalog updated from %s\n", update)
+		if flag.NArg() == 0 {
+			return
+		}
+	}
+
+	catalog, err := tools.ReadCatalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	results := tools.SearchCatalog(catalog, flag.Args())
+	if len(results) == 0 {
+		fmt.Printf("No plugins match %s\n", strings.Join(flag.Args(), " "))
+		os.Exit(1)
+	}
+	more := 0
+	if limit > 0 && len(results) > limit {
+		more = len(results) - limit
+		results = results[:limit]
+	}
+
+	installed := map[int]bool{}
+	for i, e := range results {
+		mark := ""
+		if name, ok := plugins.Installed(e); ok {
+			installed[i] = true
+			mark = " [installed]"
+			if name != e.Name {
+				mark = fmt.Sprintf(" [installed as %s]", name)
+			}
+		}
+		fmt.Printf("%2d %s %s%s\n", i+1, e.Name, e.URL, mark)
+		if e.Description != "" {
+			fmt.Printf("     %s\n", e.Description)
+		}
+		if len(e.Tags) > 0 {
+			fmt.Printf("     [%s]\n", strings.Join(e.Tags, ", "))
+		}
+	}
+	if more > 0 {
+		fmt.Printf("... and %d more, use -n to see them\n", more)
+	}
+
+	// only offer to add when someone is there to answer
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return
+	}
+	n, ok := choose(bufio.NewReader(os.Stdin), len(results))
+	if !ok {
+		return
+	}
+	if installed[n] {
+		fmt.Printf("%s is already installed\n", results[n].Name)
+		return
+	}
+	if err := add(results[n].URL); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// choose asks which result to add, returning its index.
+func choose(r *bufio.Reader, count int) (int, bool) {
+	for {
+		fmt.Printf("Add which plugin? [1-%d, Enter for none] ", count)
+		answer, err := r.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return 0, false
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= count {
+			return n - 1, true
+		}
+		if err != nil {
+			return 0, false
+		}
+	}
+}
+
+// add runs vim-add, preferring the one installed alongside this command.
+func add(url string) error {
+	bin := "vim-add"
+	if self, err := os.Executable(); err == nil {
+		sibling := filepath.Join(filepath.Dir(self), bin)
+		if _, err := os.Stat(sibling); err == nil {
+			bin = sibling
+		}
+	}
+	cmd := exec.Command(bin, url)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("vim-add failed")
+		}
+		return fmt.Errorf("failed to run vim-add: %w", err)
+	}
+	return nil
+}
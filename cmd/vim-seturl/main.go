@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() != 1 && flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s plugin [url]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+
+	name := flag.Arg(0)
+	plugin, ok := plugins[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Cannot find plugin %s\n", name)
+		os.Exit(1)
+	}
+	// without a URL origin is repaired to match the plugins file
+	if flag.NArg() == 2 {
+		plugin = plugin.SetURL(flag.Arg(1))
+	}
+
+	if _, err := os.Stat(filepath.Join(tools.PluginDir(), name)); err == nil {
+		fmt.Print(" - set remote url\n")
+		if err := tx.SetOriginURL(&plugin); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set remote url: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	plugins[name] = plugin
+
+	fmt.Print(" - rewrite files\n")
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
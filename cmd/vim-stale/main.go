@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var age string
+	var showAll bool
+	flag.StringVar(&age, "age", "", "Flag plugins with no upstream commit for this long, e.g. 90d, 26w or 2y (default: stale_after from config.json, or 1y)")
+	flag.BoolVar(&showAll, "a", false, "Also show plugins that are not stale")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	maxAge, err := tools.StaleAfter()
+	if age != "" {
+		maxAge, err = tools.ParseAge(age)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// fetching must not race vim-gc pruning the objects it fetches, or
+	// vim-check pulling into the same repos
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+
+	var args []string
+	if flag.NArg() == 0 {
+		args = plugins.SortedNames()
+	} else {
+		args = flag.Args()
+		for _, arg := range args {
+			if _, ok := plugins[arg]; !ok {
+				fmt.Fprintf(os.Stderr, "No such plugin %s\n", arg)
+				os.Exit(1)
+			}
+		}
+	}
+
+	statuses := make([]tools.StaleStatus, len(args))
+	errs := make([]error, len(args))
+	var wg sync.WaitGroup
+	for i, pluginName := range args {
+		wg.Add(1)
+		go func(i int, plugin tools.Plugin) {
+			defer wg.Done()
+			statuses[i], errs[i] = plugin.StaleStatus()
+		}(i, plugins[pluginName])
+	}
+	wg.Wait()
+
+	now := time.Now()
+	failed := false
+	for i, pluginName := range args {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "ERROR %s\n", errs[i])
+			failed = true
+			continue
+		}
+		status := statuses[i]
+		if status.Gone != "" {
+			fmt.Printf("GONE %s [%s]\n  %s\n", pluginName, plugins[pluginName].URL, status.Gone)
+			continue
+		}
+		last := fmt.Sprintf("last commit %s, %s ago", status.LastCommit.Format("2006-01-02"), tools.FormatAge(now.Sub(status.LastCommit)))
+		flagged := false
+		if status.IsStale(now, maxAge) {
+			fmt.Printf("STALE %s [%s]\n", pluginName, last)
+			flagged = true
+		}
+		if len(status.Markers) > 0 {
+			fmt.Printf("ARCHIVED %s [%s]\n", pluginName, last)
+			for _, marker := range status.Markers {
+				fmt.Printf("  %s\n", marker)
+			}
+			flagged = true
+		}
+		if !flagged && showAll {
+			fmt.Printf("OK %s [%s]\n", pluginName, last)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,27 +10,50 @@ import (
 )
 
 func main() {
-	plugins, _ := tools.Read()
-	if len(os.Args) <= 1 {
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	tx := tools.Begin(plugins)
+	if flag.NArg() == 0 {
 		fmt.Fprintf(os.Stderr, "Usage: %s plugin [plugin ...]\n", filepath.Base(os.Args[0]))
 		os.Exit(1)
 	}
-	for _, arg := range os.Args[1:] {
+	failed := false
+	for _, arg := range flag.Args() {
 		plugin, ok := plugins[arg]
 		if !ok {
 			fmt.Fprintf(os.Stderr, "cannot find %s\n", arg)
-		} else {
-			plugins[arg] = plugin.Thaw()
+			continue
+		}
+		thawed := plugin.Thaw()
+		branch, err := thawed.TrackDefaultBranch()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to check out default branch, not thawing %s: %s\n", arg, err)
+			failed = true
+			continue
 		}
+		plugins[arg] = thawed
+		fmt.Printf("THAWED %s [%s]\n", arg, branch)
 	}
 
-	if err := plugins.Write(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
-	if err := plugins.RebuildConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to rebuild configuration: %s", err)
+	if failed {
 		os.Exit(1)
 	}
 }
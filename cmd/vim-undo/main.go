@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func main() {
+	var count int
+	var force bool
+	flag.IntVar(&count, "n", 1, "Number of changes to undo")
+	flag.BoolVar(&force, "f", false, "Undo even if the plugins have changed since")
+	tools.LockWaitFlag()
+	flag.Parse()
+
+	if flag.NArg() > 0 || count < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-f] [-n count]\n", filepath.Base(os.Args[0]))
+		os.Exit(1)
+	}
+
+	lock, err := tools.LockPlugins()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+
+	plugins, err := tools.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read plugins file: %s\n", err)
+		os.Exit(1)
+	}
+	entries, err := tools.ReadJournal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	undoable := tools.Undoable(entries, count)
+	if len(undoable) == 0 {
+		fmt.Println("Nothing to undo")
+		return
+	}
+
+	tx := tools.Begin(plugins)
+	ids := []int{}
+	for _, entry := range undoable {
+		renames, err := plugins.Revert(entry, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s, use -f to undo anyway\n", err)
+			rollback(tx)
+		}
+		for newName, oldName := range renames {
+			if err := tx.Unrename(entry, newName, oldName); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to rename %s back to %s: %s\n", newName, oldName, err)
+				rollback(tx)
+			}
+		}
+		fmt.Printf("UNDO #%d %s\n", entry.ID, entry.CommandLine())
+		ids = append(ids, entry.ID)
+	}
+	tx.MarkUndo(ids)
+
+	if err := tx.Commit(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	for _, name := range plugins.SortedNames() {
+		if _, err := os.Stat(plugins[name].Dir()); err != nil {
+			fmt.Printf("%s is not installed, run vim-check to clone it\n", name)
+		}
+	}
+}
+
+// rollback undoes what has been done so far and exits.
+func rollback(tx *tools.Tx) {
+	if err := tx.Rollback(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+	}
+	os.Exit(1)
+}
@@ -112,6 +112,52 @@ func main() {
 		fmt.Print("all ok\n")
 	}
 
+	// check that origin matches the URL in the plugins file
+	drifted := []string{}
+	for _, name := range plugins.SortedNames() {
+		plugin := plugins[name]
+		if _, ok := pluginsOnDisk[name]; !ok {
+			continue
+		}
+		drift, origin, err := plugin.HasURLDrift()
+		if err != nil {
+			drifted = append(drifted, fmt.Sprintf("ERROR %s", err))
+			continue
+		}
+		if drift {
+			drifted = append(drifted, fmt.Sprintf("MISMATCH %s [origin: %s, plugins file: %s]", name, origin, plugin.URL))
+		}
+	}
+	if len(drifted) > 0 {
+		fmt.Print("\nremote urls:\n")
+		for _, d := range drifted {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+
+	// check submodules of installed plugins
+	stale := []string{}
+	for _, name := range plugins.SortedNames() {
+		plugin := plugins[name]
+		if _, ok := pluginsOnDisk[name]; !ok {
+			continue
+		}
+		submodules, err := plugin.StaleSubmodules()
+		if err != nil {
+			stale = append(stale, fmt.Sprintf("ERROR %s", err))
+			continue
+		}
+		for _, sm := range submodules {
+			stale = append(stale, fmt.Sprintf("%s %s [%s]", sm.State, name, sm.Path))
+		}
+	}
+	if len(stale) > 0 {
+		fmt.Print("\nsubmodules:\n")
+		for _, s := range stale {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+
 	// print out unused config files
 	unused := plugins.UnusedConfigFiles()
 	if len(unused) > 0 {
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Colorscheme is a single colorscheme and the plugin that provides it.
+// From and Until optionally give the time of day, as HH:MM, between which a
+// light or dark colorscheme is preferred.
+type Colorscheme struct {
+	Plugin string `json:"plugin"`
+	Scheme string `json:"scheme"`
+	From   string `json:"from,omitempty"`
+	Until  string `json:"until,omitempty"`
+}
+
+// ColorschemeSettings holds the colorschemes chosen with vim-colorscheme.
+// When a light or dark colorscheme is set it takes precedence over the
+// active one, and the colorscheme follows 'background'.
+type ColorschemeSettings struct {
+	Active Colorscheme  `json:"active"`
+	Light  *Colorscheme `json:"light,omitempty"`
+	Dark   *Colorscheme `json:"dark,omitempty"`
+}
+
+var timeOfDay = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// ValidTimeOfDay reports whether s is a time of day in 24 hour HH:MM form.
+func ValidTimeOfDay(s string) bool {
+	return timeOfDay.MatchString(s)
+}
+
+// ColorschemeSettingsPath ....
+func ColorschemeSettingsPath() string {
+	return filepath.Join(MetadataDir(), "colorscheme.json")
+}
+
+// ReadColorschemeSettings returns the colorscheme settings. When none have
+// been written yet the zero value is returned.
+func ReadColorschemeSettings() (ColorschemeSettings, error) {
+	settings := ColorschemeSettings{}
+	data, err := afero.ReadFile(Filesys, ColorschemeSettingsPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read colorscheme settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to unmarshal colorscheme settings json: %w", err)
+	}
+	return settings, nil
+}
+
+// Write ....
+func (cs ColorschemeSettings) Write() error {
+	data, err := json.MarshalIndent(cs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("conversion to JSON failed: %w", err)
+	}
+	if err := writeFile(ColorschemeSettingsPath(), data); err != nil {
+		return fmt.Errorf("failed to write colorscheme settings: %w", err)
+	}
+	return nil
+}
+
+// Schemes returns the sorted names of the colorschemes a plugin provides,
+// from the files in its colors directory.
+func (plugin Plugin) Schemes() []string {
+	ent, err := afero.ReadDir(Filesys, filepath.Join(plugin.Dir(), "colors"))
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	schemes := []string{}
+	for _, e := range ent {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".vim" && ext != ".lua") {
+			continue
+		}
+		scheme := strings.TrimSuffix(e.Name(), ext)
+		if !seen[scheme] {
+			seen[scheme] = true
+			schemes = append(schemes, scheme)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Colorschemes returns every colorscheme provided by the plugins, sorted by
+// scheme and then plugin.
+func (p Plugins) Colorschemes() []Colorscheme {
+	all := []Colorscheme{}
+	for _, name := range p.SortedNames() {
+		for _, scheme := range p[name].Schemes() {
+			all = append(all, Colorscheme{Plugin: name, Scheme: scheme})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Scheme < all[j].Scheme
+	})
+	return all
+}
+
+// FindColorscheme returns the colorschemes named scheme. If plugin is not
+// empty only that plugin is searched.
+func (p Plugins) FindColorscheme(scheme, plugin string) []Colorscheme {
+	found := []Colorscheme{}
+	for _, cs := range p.Colorschemes() {
+		if cs.Scheme == scheme && (plugin == "" || cs.Plugin == plugin) {
+			found = append(found, cs)
+		}
+	}
+	return found
+}
+
+// usable reports whether a chosen colorscheme can be loaded, which it can
+// not when its plugin has since been removed or disabled.
+func (p Plugins) usable(cs Colorscheme) bool {
+	plugin, ok := p[cs.Plugin]
+	return ok && cs.Scheme != "" && plugin.IsEnabled()
+}
+
+// fallbackColorscheme returns the colorscheme plugin used when none has been
+// chosen with vim-colorscheme: the first enabled one with a config file.
+func (p Plugins) fallbackColorscheme() (Plugin, bool) {
+	for _, name := range p.SortedNames() {
+		plugin := p[name]
+		if plugin.Colorscheme && plugin.IsEnabled() {
+			if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
+				return plugin, true
+			}
+		}
+	}
+	return Plugin{}, false
+}
+
+// ColorschemePlugins returns the names of the plugins whose colorschemes
+// RebuildConfig loads.
+func (p Plugins) ColorschemePlugins(settings ColorschemeSettings) []string {
+	if p.backgroundAware(settings) {
+		names := []string{}
+		for _, cs := range []*Colorscheme{settings.Light, settings.Dark} {
+			if cs != nil && p.usable(*cs) && (len(names) == 0 || names[0] != cs.Plugin) {
+				names = append(names, cs.Plugin)
+			}
+		}
+		return names
+	}
+	if p.usable(settings.Active) {
+		return []string{settings.Active.Plugin}
+	}
+	if plugin, ok := p.fallbackColorscheme(); ok {
+		return []string{plugin.Name}
+	}
+	return nil
+}
+
+// writeColorscheme writes the Lua that loads the chosen colorscheme.
+func (p Plugins) writeColorscheme(w io.Writer, cs Colorscheme) {
+	plugin := p[cs.Plugin]
+	if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
+		fmt.Fprintf(w, "%s\n", plugin.ConfigLoader())
+	}
+	fmt.Fprintf(w, "vim.cmd.colorscheme(%s)\n\n", luaQuote(cs.Scheme))
+}
+
+// backgroundAware reports whether a usable light or dark colorscheme is set.
+func (p Plugins) backgroundAware(settings ColorschemeSettings) bool {
+	return (settings.Light != nil && p.usable(*settings.Light)) ||
+		(settings.Dark != nil && p.usable(*settings.Dark))
+}
+
+// writeBackgroundColorschemes writes the Lua that picks between the light
+// and dark colorschemes. At startup a colorscheme whose time window contains
+// the current time wins and sets 'background' to match, and outside of the
+// windows a colorscheme without one wins; otherwise the colorscheme is
+// picked by 'background'. Setting 'background' later switches
+// to the matching colorscheme.
+func (p Plugins) writeBackgroundColorschemes(w io.Writer, settings ColorschemeSettings) {
+	loaded := map[string]bool{}
+	entries := []string{}
+	for _, bg := range []struct {
+		name string
+		cs   *Colorscheme
+	}{{"light", settings.Light}, {"dark", settings.Dark}} {
+		if bg.cs == nil || !p.usable(*bg.cs) {
+			continue
+		}
+		plugin := p[bg.cs.Plugin]
+		if _, err := os.Stat(plugin.ConfigFilePath()); err == nil && !loaded[plugin.Name] {
+			loaded[plugin.Name] = true
+			fmt.Fprintf(w, "%s\n", plugin.ConfigLoader())
+		}
+		fields := []string{"scheme = " + luaQuote(bg.cs.Scheme)}
+		if bg.cs.From != "" || bg.cs.Until != "" {
+			from, until := bg.cs.From, bg.cs.Until
+			if from == "" {
+				from = "00:00"
+			}
+			if until == "" {
+				until = "24:00"
+			}
+			fields = append(fields, "from = "+luaQuote(from), "['until'] = "+luaQuote(until))
+		}
+		entries = append(entries, fmt.Sprintf("    %s = { %s },\n", bg.name, strings.Join(fields, ", ")))
+	}
+	fmt.Fprint(w, "do\n  local colorschemes = {\n")
+	fmt.Fprint(w, strings.Join(entries, ""))
+	fmt.Fprint(w, `  }
+  local function in_window(cs, now)
+    if not cs.from then
+      return false
+    end
+    if cs.from <= cs['until'] then
+      return now >= cs.from and now < cs['until']
+    end
+    return now >= cs.from or now < cs['until']
+  end
+  local function apply(bg)
+    local cs = colorschemes[bg] or colorschemes.light or colorschemes.dark
+    pcall(vim.cmd.colorscheme, cs.scheme)
+  end
+  local now = os.date('%H:%M')
+  local windowed, chosen = false, nil
+  for _, bg in ipairs({ 'light', 'dark' }) do
+    local cs = colorschemes[bg]
+    if cs and cs.from then
+      windowed = true
+      if not chosen and in_window(cs, now) then
+        chosen = bg
+      end
+    end
+  end
+  if windowed and not chosen then
+    -- outside every window, so use the colorscheme that has none
+    for _, bg in ipairs({ 'light', 'dark' }) do
+      if colorschemes[bg] and not colorschemes[bg].from then
+        chosen = bg
+      end
+    end
+  end
+  if chosen then
+    vim.o.background = chosen
+  end
+  apply(vim.o.background)
+  vim.api.nvim_create_autocmd('OptionSet', {
+    pattern = 'background',
+    callback = function()
+      apply(vim.v.option_new)
+    end,
+  })
+end
+
+`)
+}
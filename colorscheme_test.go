@@ -0,0 +1,123 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestColorschemes(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{
+		"gruvbox":         {Name: "gruvbox", CleanName: "gruvbox", ConfigFile: "gruvbox.lua", Enabled: true, Colorscheme: true},
+		"tokyonight.nvim": {Name: "tokyonight.nvim", CleanName: "tokyonight-nvim", ConfigFile: "tokyonight-nvim.lua", Enabled: false, Colorscheme: true},
+	}
+	for name, files := range map[string][]string{
+		"gruvbox":         {"gruvbox.vim", "retro.lua"},
+		"tokyonight.nvim": {"tokyonight.lua", "tokyonight-night.lua", "retro.vim", "README"},
+	} {
+		for _, file := range files {
+			path := filepath.Join(tools.PluginDir(), name, "colors", file)
+			if err := afero.WriteFile(tools.Filesys, path, []byte{}, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("all colorschemes", func(t *testing.T) {
+		want := []tools.Colorscheme{
+			{Plugin: "gruvbox", Scheme: "gruvbox"},
+			{Plugin: "gruvbox", Scheme: "retro"},
+			{Plugin: "tokyonight.nvim", Scheme: "retro"},
+			{Plugin: "tokyonight.nvim", Scheme: "tokyonight"},
+			{Plugin: "tokyonight.nvim", Scheme: "tokyonight-night"},
+		}
+		if got := plugins.Colorschemes(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("find", func(t *testing.T) {
+		if got := plugins.FindColorscheme("retro", ""); len(got) != 2 {
+			t.Errorf("got %v, want both retro colorschemes", got)
+		}
+		want := []tools.Colorscheme{{Plugin: "tokyonight.nvim", Scheme: "retro"}}
+		if got := plugins.FindColorscheme("retro", "tokyonight.nvim"); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if got := plugins.FindColorscheme("missing", ""); len(got) != 0 {
+			t.Errorf("got %v, want none", got)
+		}
+	})
+
+	t.Run("rebuild uses active colorscheme", func(t *testing.T) {
+		settings := tools.ColorschemeSettings{Active: tools.Colorscheme{Plugin: "gruvbox", Scheme: "retro"}}
+		if err := settings.Write(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tools.ReadColorschemeSettings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != settings {
+			t.Errorf("got %v, want %v", got, settings)
+		}
+		if err := plugins.RebuildConfig(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := afero.ReadFile(tools.Filesys, tools.AllPluginsPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "-- colorscheme\nvim.cmd.colorscheme('retro')\n") {
+			t.Errorf("colorscheme not loaded in %q", data)
+		}
+	})
+
+	t.Run("rebuild follows background", func(t *testing.T) {
+		settings := tools.ColorschemeSettings{
+			Active: tools.Colorscheme{Plugin: "gruvbox", Scheme: "retro"},
+			Light:  &tools.Colorscheme{Plugin: "gruvbox", Scheme: "gruvbox", From: "07:00"},
+			Dark:   &tools.Colorscheme{Plugin: "tokyonight.nvim", Scheme: "tokyonight"},
+		}
+		if err := settings.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if err := plugins.RebuildConfig(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := afero.ReadFile(tools.Filesys, tools.AllPluginsPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// tokyonight.nvim is disabled so only the light colorscheme is used
+		want := "  local colorschemes = {\n    light = { scheme = 'gruvbox', from = '07:00', ['until'] = '24:00' },\n  }\n"
+		if !strings.Contains(string(data), want) {
+			t.Errorf("colorschemes not in %q", data)
+		}
+		if strings.Contains(string(data), "'retro'") {
+			t.Errorf("active colorscheme used in %q", data)
+		}
+	})
+}
+
+func TestValidTimeOfDay(t *testing.T) {
+	for s, want := range map[string]bool{
+		"00:00": true,
+		"07:30": true,
+		"23:59": true,
+		"24:00": false,
+		"7:30":  false,
+		"07:60": false,
+		"":      false,
+	} {
+		if got := tools.ValidTimeOfDay(s); got != want {
+			t.Errorf("ValidTimeOfDay(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// CloneOptions ....
+type CloneOptions struct {
+	Depth  int    `json:"depth,omitempty"`
+	Filter string `json:"filter,omitempty"`
+}
+
+// Config holds settings for the tools themselves, as opposed to the
+// plugins they manage.
+type Config struct {
+	Clone CloneOptions `json:"clone"`
+	// Profile makes RebuildConfig time the loading of each config file;
+	// see vim-profile.
+	Profile bool `json:"profile,omitempty"`
+	// LockWait is how long to wait for another vim-tools process to
+	// finish, e.g. "30s" or "forever"; see LockPlugins.
+	LockWait string `json:"lock_wait,omitempty"`
+	// Backups is how many backups of the plugins file to keep; 0 means
+	// DefaultBackups and a negative number none at all.
+	Backups int `json:"backups,omitempty"`
+	// StaleAfter is how long a plugin may go without an upstream commit
+	// before vim-stale flags it, e.g. "365d" or "2y"; see ParseAge.
+	StaleAfter string `json:"stale_after,omitempty"`
+}
+
+// ToolConfigDir ....
+func ToolConfigDir() string {
+	configHome, ok := os.LookupEnv("XDG_CONFIG_HOME")
+	if !ok {
+		panic("XDG_CONFIG_HOME must be set.")
+	}
+	return filepath.Join(configHome, "vim-tools")
+}
+
+// ToolConfigPath ....
+func ToolConfigPath() string {
+	return filepath.Join(ToolConfigDir(), "config.json")
+}
+
+// ReadConfig returns the tool configuration. A missing configuration file
+// is not an error; the defaults are returned instead.
+func ReadConfig() (Config, error) {
+	config := Config{}
+	data, err := afero.ReadFile(Filesys, ToolConfigPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal config json: %w", err)
+	}
+	return config, nil
+}
+
+// Write ....
+func (config Config) Write() error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("conversion to JSON failed: %w", err)
+	}
+	if err := Filesys.MkdirAll(ToolConfigDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", ToolConfigDir(), err)
+	}
+	if err := writeFile(ToolConfigPath(), data); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultBackups is how many backups of the plugins file are kept when the
+// config file does not say.
+const DefaultBackups = 5
+
+// BackupDir ....
+func BackupDir() string {
+	return filepath.Join(MetadataDir(), "backups")
+}
+
+// BackupPath returns the path of the nth most recent backup of the plugins
+// file, counting from 1.
+func BackupPath(n int) string {
+	return filepath.Join(BackupDir(), fmt.Sprintf("%s.%d", filepath.Base(PluginsFilePath()), n))
+}
+
+// writeFile replaces the file at path with data so that a crash leaves
+// either the old or the new contents, never a mix. The data is synced to
+// disk before the file is renamed into place, and the directory after. A
+// file that is replaced keeps its permissions.
+func writeFile(path string, data []byte) error {
+	mode := fs.FileMode(0o644)
+	if fi, err := Filesys.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	tmp, err := afero.TempFile(Filesys, dir, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	written := false
+	defer func() {
+		if !written {
+			Filesys.Remove(tmp.Name())
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp.Name(), err)
+	}
+	if err := Filesys.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", tmp.Name(), err)
+	}
+	if err := Filesys.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename of %s failed: %w", path, err)
+	}
+	written = true
+	return syncDir(dir)
+}
+
+// syncDir makes a rename in dir durable. Not every platform can open a
+// directory to sync it, so failing to open it is not an error.
+func syncDir(dir string) error {
+	d, err := Filesys.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+	return nil
+}
+
+// backupPluginsFile keeps a copy of the plugins file before it is replaced
+// by data, dropping the oldest of the backups to keep no more than n.
+func backupPluginsFile(data []byte, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	current, err := afero.ReadFile(Filesys, PluginsFilePath())
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && bytes.Equal(current, data)) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read plugins file for backup: %w", err)
+	}
+	if err := Filesys.MkdirAll(BackupDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", BackupDir(), err)
+	}
+	if err := Filesys.Remove(BackupPath(n)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove oldest backup: %w", err)
+	}
+	for i := n - 1; i >= 1; i-- {
+		if err := Filesys.Rename(BackupPath(i), BackupPath(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+	}
+	return writeFile(BackupPath(1), current)
+}
+
+// backups returns how many backups of the plugins file to keep.
+func backups() (int, error) {
+	config, err := ReadConfig()
+	if err != nil {
+		return 0, err
+	}
+	if config.Backups == 0 {
+		return DefaultBackups, nil
+	}
+	return config.Backups, nil
+}
@@ -0,0 +1,71 @@
+package tools_test
+
+import (
+	"strings"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestDurableWrite(t *testing.T) {
+	prepareEnv(t)
+
+	write := func(t *testing.T, version string) {
+		t.Helper()
+		plugins := tools.Plugins{"a": {Name: "a", Enabled: true, Version: version}}
+		if err := plugins.Write(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("keeps permissions", func(t *testing.T) {
+		write(t, "v1")
+		if err := tools.Filesys.Chmod(tools.PluginsFilePath(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		write(t, "v2")
+		fi, err := tools.Filesys.Stat(tools.PluginsFilePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o600 {
+			t.Errorf("got mode %v, want 0600", fi.Mode().Perm())
+		}
+	})
+
+	t.Run("keeps backups", func(t *testing.T) {
+		if err := (tools.Config{Backups: 2}).Write(); err != nil {
+			t.Fatal(err)
+		}
+		write(t, "v3")
+		write(t, "v3") // unchanged, so no backup
+		write(t, "v4")
+		for n, want := range map[int]string{1: "v3", 2: "v2"} {
+			data, err := afero.ReadFile(tools.Filesys, tools.BackupPath(n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), `"version": "`+want+`"`) {
+				t.Errorf("backup %d is %s, want version %s", n, data, want)
+			}
+		}
+		if _, err := tools.Filesys.Stat(tools.BackupPath(3)); err == nil {
+			t.Error("too many backups kept")
+		}
+		if ents, _ := afero.ReadDir(tools.Filesys, tools.MetadataDir()); len(ents) != 2 {
+			t.Errorf("got %d entries in metadata dir, want all.json and backups", len(ents))
+		}
+	})
+
+	t.Run("reports errors", func(t *testing.T) {
+		tools.Filesys = afero.NewReadOnlyFs(tools.Filesys)
+		plugins := tools.Plugins{"a": {Name: "a"}}
+		if err := plugins.Write(); err == nil {
+			t.Error("expected an error writing the plugins file")
+		}
+		if err := plugins.RebuildConfig(); err == nil {
+			t.Error("expected an error rebuilding the config")
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Kinds of version a plugin can be frozen to.
+const (
+	VersionCommit = "commit"
+	VersionTag    = "tag"
+	VersionBranch = "branch"
+)
+
+var (
+	commitHash = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	tagLike    = regexp.MustCompile(`^v?[0-9]`)
+)
+
+// VersionKind works out whether the version a plugin is frozen to is a
+// commit, a tag or a branch. The plugin's clone is asked when there is one,
+// otherwise the version is guessed at from its appearance.
+func (plugin *Plugin) VersionKind() string {
+	if commitHash.MatchString(plugin.Version) {
+		return VersionCommit
+	}
+	if _, err := os.Stat(plugin.Dir()); err == nil {
+		if _, err := plugin.RunGit("rev-parse", "--verify", "--quiet", "refs/tags/"+plugin.Version); err == nil {
+			return VersionTag
+		}
+		if _, err := plugin.RunGit("rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+plugin.Version); err == nil {
+			return VersionBranch
+		}
+	}
+	if tagLike.MatchString(plugin.Version) {
+		return VersionTag
+	}
+	return VersionBranch
+}
+
+// githubRepo returns the owner/repo shorthand for GitHub URLs, or "".
+func githubRepo(url string) string {
+	for _, prefix := range []string{"https://github.com/", "git@github.com:"} {
+		if strings.HasPrefix(url, prefix) {
+			repo := strings.TrimSuffix(strings.TrimPrefix(url, prefix), ".git")
+			if shortRepo.MatchString(repo) {
+				return repo
+			}
+		}
+	}
+	return ""
+}
+
+// luaQuote quotes s as a Lua string literal.
+func luaQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(s) + "'"
+}
+
+// vimQuote quotes s as a Vim script literal string.
+func vimQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// exportedConfig returns the Lua that loads a plugin's config file, or "" if
+// it has none.
+func (plugin Plugin) exportedConfig() string {
+	if _, err := Filesys.Stat(plugin.ConfigFilePath()); err != nil {
+		return ""
+	}
+	return plugin.ConfigLoader()
+}
+
+// Export writes the plugins as a spec for another plugin manager.
+func (p Plugins) Export(format string, w io.Writer) error {
+	// a bufio.Writer keeps the first write error and returns it from Flush
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatLazy:
+		p.exportLazy(bw)
+	case FormatPacker:
+		p.exportPacker(bw)
+	case FormatPlug:
+		p.exportPlug(bw)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s spec: %w", format, err)
+	}
+	return nil
+}
+
+func (p Plugins) exportLazy(w io.Writer) {
+	fmt.Fprint(w, "return {\n")
+	for _, name := range p.SortedNames() {
+		plugin := p[name]
+		fields := []string{}
+		if repo := githubRepo(plugin.URL); repo != "" {
+			fields = append(fields, luaQuote(repo))
+		} else {
+			fields = append(fields, "url = "+luaQuote(plugin.URL))
+		}
+		if plugin.Name != nameFromURL(plugin.URL) {
+			fields = append(fields, "name = "+luaQuote(plugin.Name))
+		}
+		if plugin.HasVersion() {
+			fields = append(fields, fmt.Sprintf("%s = %s", plugin.VersionKind(), luaQuote(plugin.Version)))
+		}
+		if plugin.IsDisabled() {
+			fields = append(fields, "enabled = false")
+		}
+		if config := plugin.exportedConfig(); config != "" {
+			fields = append(fields, fmt.Sprintf("config = function() %s end", config))
+		}
+		fmt.Fprintf(w, "  { %s },\n", strings.Join(fields, ", "))
+	}
+	fmt.Fprint(w, "}\n")
+}
+
+func (p Plugins) exportPacker(w io.Writer) {
+	fmt.Fprint(w, "return require('packer').startup(function(use)\n")
+	for _, name := range p.SortedNames() {
+		plugin := p[name]
+		repo := githubRepo(plugin.URL)
+		if repo == "" {
+			repo = plugin.URL
+		}
+		fields := []string{luaQuote(repo)}
+		if plugin.Name != nameFromURL(plugin.URL) {
+			fields = append(fields, "as = "+luaQuote(plugin.Name))
+		}
+		if plugin.HasVersion() {
+			fields = append(fields, fmt.Sprintf("%s = %s", plugin.VersionKind(), luaQuote(plugin.Version)))
+		}
+		if plugin.IsDisabled() {
+			fields = append(fields, "disable = true")
+		}
+		if config := plugin.exportedConfig(); config != "" {
+			fields = append(fields, "config = "+luaQuote(config))
+		}
+		fmt.Fprintf(w, "  use { %s }\n", strings.Join(fields, ", "))
+	}
+	fmt.Fprint(w, "end)\n")
+}
+
+func (p Plugins) exportPlug(w io.Writer) {
+	configs := []string{}
+	fmt.Fprint(w, "call plug#begin()\n")
+	for _, name := range p.SortedNames() {
+		plugin := p[name]
+		repo := githubRepo(plugin.URL)
+		if repo == "" {
+			repo = plugin.URL
+		}
+		opts := []string{}
+		if plugin.Name != nameFromURL(plugin.URL) {
+			opts = append(opts, "'as': "+vimQuote(plugin.Name))
+		}
+		if plugin.HasVersion() {
+			opts = append(opts, fmt.Sprintf("'%s': %s", plugin.VersionKind(), vimQuote(plugin.Version)))
+		}
+		line := "Plug " + vimQuote(repo)
+		if len(opts) > 0 {
+			line = fmt.Sprintf("%s, { %s }", line, strings.Join(opts, ", "))
+		}
+		// vim-plug has no notion of a disabled plugin
+		if plugin.IsDisabled() {
+			line = "\" " + line
+		} else if config := plugin.exportedConfig(); config != "" {
+			configs = append(configs, "lua "+config)
+		}
+		fmt.Fprintf(w, "%s\n", line)
+	}
+	fmt.Fprint(w, "call plug#end()\n")
+	if len(configs) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(configs, "\n"))
+	}
+}
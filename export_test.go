@@ -0,0 +1,186 @@
+package tools_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func exportPlugins() tools.Plugins {
+	return tools.Plugins{
+		"plugin1.nvim": {
+			Name:       "plugin1.nvim",
+			URL:        "https://github.com/user/plugin1.nvim",
+			Enabled:    true,
+			ConfigFile: "plugin1-nvim.lua",
+			CleanName:  "plugin1-nvim",
+			Version:    "v1.2.0",
+		},
+		"plugin-a": {
+			Name:       "plugin-a",
+			URL:        "git@github.com:SomeUser/plugin-a",
+			Enabled:    true,
+			ConfigFile: "plugin-a.lua",
+			CleanName:  "plugin-a",
+			Version:    "0f1e2d3c4b5a",
+		},
+		"someotherplugin.nvim": {
+			Name:       "someotherplugin.nvim",
+			URL:        "https://gitlab.com/SomeOtherUser/someotherplugin.nvim",
+			Enabled:    false,
+			ConfigFile: "someotherplugin-nvim.lua",
+			CleanName:  "someotherplugin-nvim",
+			Version:    "develop",
+		},
+		"dracula": {
+			Name:        "dracula",
+			URL:         "https://github.com/dracula/vim",
+			Colorscheme: true,
+			Enabled:     true,
+			ConfigFile:  "dracula.lua",
+			CleanName:   "dracula",
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	prepareEnv(t)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			tools.FormatLazy,
+			`return {
+  { 'dracula/vim', name = 'dracula' },
+  { 'SomeUser/plugin-a', commit = '0f1e2d3c4b5a' },
+  { 'user/plugin1.nvim', tag = 'v1.2.0' },
+  { url = 'https://gitlab.com/SomeOtherUser/someotherplugin.nvim', branch = 'develop', enabled = false },
+}
+`,
+		},
+		{
+			tools.FormatPacker,
+			`return require('packer').startup(function(use)
+  use { 'dracula/vim', as = 'dracula' }
+  use { 'SomeUser/plugin-a', commit = '0f1e2d3c4b5a' }
+  use { 'user/plugin1.nvim', tag = 'v1.2.0' }
+  use { 'https://gitlab.com/SomeOtherUser/someotherplugin.nvim', branch = 'develop', disable = true }
+end)
+`,
+		},
+		{
+			tools.FormatPlug,
+			`call plug#begin()
+Plug 'dracula/vim', { 'as': 'dracula' }
+Plug 'SomeUser/plugin-a', { 'commit': '0f1e2d3c4b5a' }
+Plug 'user/plugin1.nvim', { 'tag': 'v1.2.0' }
+" Plug 'https://gitlab.com/SomeOtherUser/someotherplugin.nvim', { 'branch': 'develop' }
+call plug#end()
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := exportPlugins().Export(tt.format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExportConfigAndQuoting(t *testing.T) {
+	prepareEnv(t)
+	afero.WriteFile(tools.Filesys, filepath.Join(tools.ConfigFileDir(), "it-s.lua"), []byte(""), 0o644)
+
+	plugins := tools.Plugins{
+		"it's": {
+			Name:       "it's",
+			URL:        "https://github.com/user/plugin",
+			Enabled:    true,
+			ConfigFile: "it-s.lua",
+			CleanName:  "it-s",
+			Version:    "o'clock",
+		},
+	}
+	tests := map[string]string{
+		tools.FormatLazy: `return {
+  { 'user/plugin', name = 'it\'s', branch = 'o\'clock', config = function() require'plugins.it-s' end },
+}
+`,
+		tools.FormatPacker: `return require('packer').startup(function(use)
+  use { 'user/plugin', as = 'it\'s', branch = 'o\'clock', config = 'require\'plugins.it-s\'' }
+end)
+`,
+		tools.FormatPlug: `call plug#begin()
+Plug 'user/plugin', { 'as': 'it''s', 'branch': 'o''clock' }
+call plug#end()
+
+lua require'plugins.it-s'
+`,
+	}
+	for format, want := range tests {
+		var buf bytes.Buffer
+		if err := plugins.Export(format, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: got %q, want %q", format, buf.String(), want)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	prepareEnv(t)
+
+	for _, format := range []string{tools.FormatLazy, tools.FormatPacker, tools.FormatPlug} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := exportPlugins().Export(format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			imported, err := tools.ParseImport(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plugins := tools.Plugins{}
+			plugins.Import(imported)
+			for name, want := range exportPlugins() {
+				got := plugins[name]
+				// the importer only sees GitHub URLs in their short form
+				got.URL, want.URL = "", ""
+				got.Colorscheme, want.Colorscheme = false, false
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %#v, want %#v", got, want)
+				}
+			}
+		})
+	}
+}
+
+// failingWriter fails every write, like a full disk.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestExportWriteError(t *testing.T) {
+	prepareEnv(t)
+
+	for _, format := range []string{tools.FormatLazy, tools.FormatPacker, tools.FormatPlug} {
+		if err := exportPlugins().Export(format, failingWriter{}); err == nil {
+			t.Errorf("%s: expected an error", format)
+		}
+	}
+}
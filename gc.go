@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GCTimeout is how long git gc may take on a single repository.
+const GCTimeout = 10 * time.Minute
+
+// MinLargeRepo is the least a repository must take up to be flagged as
+// large when no threshold is given.
+const MinLargeRepo = 20 * 1024 * 1024
+
+// Usage is the disk space taken up by a plugin's clone.
+type Usage struct {
+	Name     string
+	Git      int64 // the .git directory
+	Worktree int64 // everything else
+}
+
+// Total ....
+func (u Usage) Total() int64 {
+	return u.Git + u.Worktree
+}
+
+// RepoUsage returns the disk space taken up by the clone in dir.
+func RepoUsage(name, dir string) (Usage, error) {
+	usage := Usage{Name: name}
+	gitDir := filepath.Join(dir, ".git")
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if path == gitDir || strings.HasPrefix(path, gitDir+string(filepath.Separator)) {
+			usage.Git += fi.Size()
+		} else {
+			usage.Worktree += fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("%s: failed to measure disk usage: %w", name, err)
+	}
+	return usage, nil
+}
+
+// LargeRepos returns the repositories that take up more than threshold
+// bytes. With no threshold a repository is large when it takes up more than
+// four times the median, and at least MinLargeRepo.
+func LargeRepos(usages []Usage, threshold int64) map[string]bool {
+	large := map[string]bool{}
+	if len(usages) == 0 {
+		return large
+	}
+	if threshold <= 0 {
+		totals := make([]int64, len(usages))
+		for i, u := range usages {
+			totals[i] = u.Total()
+		}
+		sort.Slice(totals, func(i, j int) bool { return totals[i] < totals[j] })
+		threshold = 4 * totals[len(totals)/2]
+		if threshold < MinLargeRepo {
+			threshold = MinLargeRepo
+		}
+	}
+	for _, u := range usages {
+		if u.Total() > threshold {
+			large[u.Name] = true
+		}
+	}
+	return large
+}
+
+// IsRepo reports whether the plugin's directory is a git clone.
+func (plugin Plugin) IsRepo() bool {
+	_, err := os.Stat(filepath.Join(plugin.Dir(), ".git"))
+	return err == nil
+}
+
+// GC prunes unreachable objects from the plugin's clone and repacks it.
+func (plugin *Plugin) GC(aggressive bool) error {
+	if !plugin.IsRepo() {
+		return errors.New("not a git repository")
+	}
+	if _, err := plugin.runGitTimeout(plugin.Dir(), GCTimeout, "prune"); err != nil {
+		return err
+	}
+	args := []string{"gc", "--quiet"}
+	if aggressive {
+		args = append(args, "--aggressive")
+	}
+	_, err := plugin.runGitTimeout(plugin.Dir(), GCTimeout, args...)
+	return err
+}
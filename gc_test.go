@@ -0,0 +1,58 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestDiskUsage(t *testing.T) {
+	t.Run("repo", func(t *testing.T) {
+		dir := t.TempDir()
+		for path, size := range map[string]int{
+			".git/HEAD":              20,
+			".git/objects/pack/x":    1000,
+			"lua/plugin/init.lua":    300,
+			"README.md":              50,
+			".github/workflows/x.ym": 7,
+		} {
+			path = filepath.Join(dir, path)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		usage, err := tools.RepoUsage("plugin", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (tools.Usage{Name: "plugin", Git: 1020, Worktree: 357}); usage != want {
+			t.Errorf("got %+v, want %+v", usage, want)
+		}
+		if size, err := tools.DiskUsage(dir); err != nil || size != usage.Total() {
+			t.Errorf("DiskUsage() = %d, %v, want %d", size, err, usage.Total())
+		}
+	})
+
+	t.Run("large", func(t *testing.T) {
+		const mib = 1024 * 1024
+		usages := []tools.Usage{
+			{Name: "a", Git: 1 * mib},
+			{Name: "b", Git: 2 * mib},
+			{Name: "c", Git: 3 * mib},
+			{Name: "d", Git: 15 * mib, Worktree: 1 * mib},
+			{Name: "e", Git: 200 * mib},
+		}
+		if got, want := tools.LargeRepos(usages, 0), map[string]bool{"e": true}; !reflect.DeepEqual(got, want) {
+			t.Errorf("LargeRepos() = %v, want %v", got, want)
+		}
+		if got, want := tools.LargeRepos(usages, 10*mib), map[string]bool{"d": true, "e": true}; !reflect.DeepEqual(got, want) {
+			t.Errorf("LargeRepos(10 MiB) = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CloneOptions returns the plugin's own clone options or, when it has none,
+// the global ones.
+func (plugin *Plugin) CloneOptions() (CloneOptions, error) {
+	if plugin.Clone != nil {
+		return *plugin.Clone, nil
+	}
+	config, err := ReadConfig()
+	if err != nil {
+		return CloneOptions{}, err
+	}
+	return config.Clone, nil
+}
+
+func (plugin *Plugin) cloneArgs() ([]string, error) {
+	opts, err := plugin.CloneOptions()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"clone", "--quiet", "--recurse-submodules"}
+	if opts.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", opts.Depth))
+	}
+	if opts.Filter != "" {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	if plugin.HasVersion() {
+		// only branches and tags can be given to --branch
+		refs, err := plugin.runGitFromDir(PluginDir(), "ls-remote", plugin.URL, plugin.Version)
+		if err != nil {
+			return nil, err
+		}
+		if refs != "" {
+			args = append(args, "--branch", plugin.Version)
+		}
+	}
+	return append(args, plugin.URL, plugin.Name), nil
+}
+
+// IsShallow ....
+func (plugin *Plugin) IsShallow() (bool, error) {
+	out, err := plugin.RunGit("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return out == "true", nil
+}
+
+// Fetch updates the remote-tracking branches and tags from the plugin's URL.
+// Shallow repositories stay shallow.
+func (plugin *Plugin) Fetch() error {
+	args := []string{"fetch", "--quiet", "--tags"}
+	shallow, err := plugin.IsShallow()
+	if err != nil {
+		return err
+	}
+	if shallow {
+		opts, err := plugin.CloneOptions()
+		if err != nil {
+			return err
+		}
+		if opts.Depth > 0 {
+			args = append(args, fmt.Sprintf("--depth=%d", opts.Depth))
+		}
+	}
+	args = append(args, plugin.URL, "+refs/heads/*:refs/remotes/origin/*")
+	_, err = plugin.RunGit(args...)
+	return err
+}
+
+// deepen tries to make rev available in a shallow repository, first by
+// fetching it directly and then by fetching the complete history.
+func (plugin *Plugin) deepen(rev string) error {
+	shallow, err := plugin.IsShallow()
+	if err != nil {
+		return err
+	}
+	if !shallow {
+		return fmt.Errorf("%s: cannot find %s", plugin.Name, rev)
+	}
+	if _, err := plugin.RunGit("fetch", "--quiet", "--depth=1", plugin.URL, rev); err == nil {
+		return nil
+	}
+	_, err = plugin.RunGit(
+		"fetch", "--quiet", "--unshallow", "--tags", plugin.URL,
+		"+refs/heads/*:refs/remotes/origin/*",
+	)
+	return err
+}
+
+// ResolveRef returns the commit hash for the given branch, tag or commit.
+// Branches are looked up under origin first so that a stale local branch
+// is never preferred over what was last fetched.
+func (plugin *Plugin) ResolveRef(ref string) (string, error) {
+	for _, r := range []string{"origin/" + ref, ref} {
+		out, err := plugin.RunGit("rev-parse", "--verify", "--quiet", r+"^{commit}")
+		if err == nil && out != "" {
+			return out, nil
+		}
+	}
+	return "", fmt.Errorf("%s: cannot resolve %s", plugin.Name, ref)
+}
+
+// Checkout fetches and resets the working tree to the version the plugin
+// is frozen to.
+func (plugin *Plugin) Checkout() error {
+	if !plugin.HasVersion() {
+		return fmt.Errorf("%s: plugin is not frozen", plugin.Name)
+	}
+	if err := plugin.Fetch(); err != nil {
+		return err
+	}
+	commit, err := plugin.ResolveRef(plugin.Version)
+	if err != nil {
+		if err := plugin.deepen(plugin.Version); err != nil {
+			return err
+		}
+		if commit, err = plugin.ResolveRef(plugin.Version); err != nil {
+			return err
+		}
+	}
+	if _, err := plugin.RunGit("reset", "--quiet", "--hard", commit); err != nil {
+		return err
+	}
+	return plugin.UpdateSubmodules()
+}
+
+// CurrentCommit ....
+func (plugin *Plugin) CurrentCommit() (string, error) {
+	return plugin.RunGit("rev-parse", "HEAD")
+}
+
+// DefaultBranch asks the remote which branch its HEAD points to.
+func (plugin *Plugin) DefaultBranch() (string, error) {
+	out, err := plugin.RunGit("ls-remote", "--symref", plugin.URL, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && f[0] == "ref:" && f[2] == "HEAD" {
+			return strings.TrimPrefix(f[1], "refs/heads/"), nil
+		}
+	}
+	return "", fmt.Errorf("%s: cannot determine default branch", plugin.Name)
+}
+
+// TrackDefaultBranch checks out the remote's default branch and sets it up
+// to track origin. The name of the branch is returned.
+func (plugin *Plugin) TrackDefaultBranch() (string, error) {
+	branch, err := plugin.DefaultBranch()
+	if err != nil {
+		return "", err
+	}
+	if err := plugin.TrackBranch(branch); err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+// TrackBranch checks out the given remote branch, creating or resetting the
+// local branch of the same name, and sets it up to track origin.
+func (plugin *Plugin) TrackBranch(branch string) error {
+	// single branch and shallow clones only know about one ref
+	if _, err := plugin.RunGit("remote", "set-branches", "origin", "*"); err != nil {
+		return err
+	}
+	if err := plugin.Fetch(); err != nil {
+		return err
+	}
+	if _, err := plugin.RunGit("checkout", "--quiet", "-B", branch, "--track", "origin/"+branch); err != nil {
+		return err
+	}
+	return plugin.UpdateSubmodules()
+}
+
+// UpdateSubmodules syncs submodule URLs with .gitmodules and checks out the
+// commits recorded by the superproject. It is a no-op for plugins without
+// submodules.
+func (plugin *Plugin) UpdateSubmodules() error {
+	if _, err := plugin.RunGit("submodule", "--quiet", "sync", "--recursive"); err != nil {
+		return err
+	}
+	_, err := plugin.RunGit("submodule", "--quiet", "update", "--init", "--recursive")
+	return err
+}
+
+// Submodule ....
+type Submodule struct {
+	Path  string
+	State string
+}
+
+// Submodule states as reported by 'git submodule status'.
+const (
+	SubmoduleUninitialized = "UNINITIALIZED"
+	SubmoduleOutdated      = "OUTDATED"
+	SubmoduleConflict      = "CONFLICT"
+)
+
+// StaleSubmodules returns the submodules that are not initialized or are not
+// checked out at the commit recorded by the plugin.
+func (plugin *Plugin) StaleSubmodules() ([]Submodule, error) {
+	out, err := plugin.RunGit("submodule", "status", "--recursive")
+	if err != nil {
+		return nil, err
+	}
+	stale := []Submodule{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		f := strings.Fields(line[1:])
+		if len(f) < 2 {
+			continue
+		}
+		switch line[0] {
+		case '-':
+			stale = append(stale, Submodule{Path: f[1], State: SubmoduleUninitialized})
+		case '+':
+			stale = append(stale, Submodule{Path: f[1], State: SubmoduleOutdated})
+		case 'U':
+			stale = append(stale, Submodule{Path: f[1], State: SubmoduleConflict})
+		}
+	}
+	return stale, nil
+}
+
+// OriginURL ....
+func (plugin *Plugin) OriginURL() (string, error) {
+	return plugin.RunGit("remote", "get-url", "origin")
+}
+
+// SetOriginURL points origin at the plugin's URL.
+func (plugin *Plugin) SetOriginURL() error {
+	_, err := plugin.RunGit("remote", "set-url", "origin", plugin.URL)
+	return err
+}
+
+// HasURLDrift reports whether origin points somewhere other than the
+// plugin's URL. The current origin URL is returned as well.
+func (plugin *Plugin) HasURLDrift() (bool, string, error) {
+	origin, err := plugin.OriginURL()
+	if err != nil {
+		return false, "", err
+	}
+	return normalizeURL(origin) != normalizeURL(plugin.URL), origin, nil
+}
+
+// normalizeURL removes the parts of a URL that do not change which
+// repository it refers to.
+func normalizeURL(url string) string {
+	url = strings.TrimRight(url, "/")
+	return strings.TrimSuffix(url, ".git")
+}
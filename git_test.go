@@ -0,0 +1,85 @@
+package tools_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+// prepareGitEnv is prepareEnv for tests that run git, which needs the
+// plugins on the real filesystem.
+func prepareGitEnv(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(v+"_NAME", "test")
+		t.Setenv(v+"_EMAIL", "test@example.com")
+	}
+
+	tools.Filesys = afero.NewOsFs()
+	t.Cleanup(func() { tools.Filesys = afero.NewMemMapFs() })
+	for _, d := range []string{tools.MetadataDir(), tools.PluginDir(), tools.ConfigFileDir()} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// git runs git in dir and returns its output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile commits content to file in the repository at dir.
+func commitFile(t *testing.T, dir, file, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", file)
+	git(t, dir, "commit", "--quiet", "--message", fmt.Sprintf("%s: %s", file, content))
+	return git(t, dir, "rev-parse", "HEAD")
+}
+
+// upstreamRepo creates a repository outside the plugin directory with
+// commits commits on main, tagging the nth v0.n.
+func upstreamRepo(t *testing.T, commits int) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "upstream")
+	git(t, t.TempDir(), "init", "--quiet", "--initial-branch=main", dir)
+	commitFile(t, dir, "other.txt", "other")
+	for i := 1; i <= commits; i++ {
+		commitFile(t, dir, "file.txt", fmt.Sprint(i))
+		git(t, dir, "tag", fmt.Sprintf("v0.%d", i))
+	}
+	return dir
+}
+
+// clonePlugin clones url into the plugin directory as name.
+func clonePlugin(t *testing.T, url, name string, args ...string) tools.Plugin {
+	t.Helper()
+	args = append(append([]string{"clone", "--quiet"}, args...), url, filepath.Join(tools.PluginDir(), name))
+	git(t, tools.PluginDir(), args...)
+	return tools.Plugin{Name: name, URL: url, Enabled: true}
+}
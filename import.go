@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ImportedPlugin is a plugin as described by another plugin manager.
+type ImportedPlugin struct {
+	URL     string
+	Name    string
+	Version string
+	Branch  string // the branch the spec tracks, if it names one
+	Enabled bool
+	Depends []string // URLs of the plugins the spec lists as dependencies
+}
+
+// Import formats.
+const (
+	FormatLazy   = "lazy"
+	FormatPacker = "packer"
+	FormatPlug   = "plug"
+)
+
+var shortRepo = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// isRepo reports whether s looks like a repository rather than some other
+// string found in a spec, such as a key mapping or a module name.
+func isRepo(s string) bool {
+	return shortRepo.MatchString(s) || strings.Contains(s, "://") || strings.HasPrefix(s, "git@")
+}
+
+// expandRepo turns the GitHub shorthand used by all the supported plugin
+// managers into a URL.
+func expandRepo(s string) string {
+	if shortRepo.MatchString(s) {
+		return "https://github.com/" + s
+	}
+	return s
+}
+
+// exactVersion matches a lazy.nvim version that names a single release
+// rather than a range.
+var exactVersion = regexp.MustCompile(`^=?(v?\d+(\.\d+){0,2}([-+][\w.]+)?)$`)
+
+// pinnedVersion picks the most specific of the pins a spec may have. A
+// version is a lazy.nvim semver version, which is only used when it names a
+// single release; a range such as '*' or '^1.2' cannot be frozen to.
+func pinnedVersion(commit, tag, version, branch string) string {
+	m := exactVersion.FindStringSubmatch(version)
+	switch {
+	case commit != "":
+		return commit
+	case tag != "":
+		return tag
+	case m != nil:
+		return m[1]
+	default:
+		return branch
+	}
+}
+
+// ParseImport reads plugins in the given format.
+func ParseImport(format string, r io.Reader) ([]ImportedPlugin, error) {
+	switch format {
+	case FormatLazy:
+		return ParseLazy(r)
+	case FormatPacker:
+		return ParsePacker(r)
+	case FormatPlug:
+		return ParsePlug(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// ParseLazy reads lazy.nvim plugin specs. Every table in the file is
+// searched for specs, so both a plain 'return { ... }' spec file and a
+// call to require('lazy').setup({ ... }) are understood.
+func ParseLazy(r io.Reader) ([]ImportedPlugin, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lazy.nvim specs: %w", err)
+	}
+	imported := []ImportedPlugin{}
+	p := newLuaParser(string(src))
+	for p.peek().kind != tokEOF {
+		if p.next().is(tokPunct, "{") {
+			imported = lazySpecs(p.parseTable(), imported)
+		}
+	}
+	return imported, nil
+}
+
+func lazySpecs(v luaValue, imported []ImportedPlugin) []ImportedPlugin {
+	switch v.kind {
+	case luaString:
+		if isRepo(v.str) {
+			imported = append(imported, ImportedPlugin{URL: expandRepo(v.str), Enabled: true})
+		}
+	case luaTable:
+		repo := lazyRepo(v)
+		if repo == "" {
+			// a list of specs, or the options table with its own list
+			for _, spec := range v.list {
+				imported = lazySpecs(spec, imported)
+			}
+			if spec, ok := v.fields["spec"]; ok {
+				imported = lazySpecs(spec, imported)
+			}
+			return imported
+		}
+		deps, hasDeps := v.fields["dependencies"]
+		imported = append(imported, ImportedPlugin{
+			URL:     expandRepo(repo),
+			Name:    v.field("name"),
+			Version: pinnedVersion(v.field("commit"), v.field("tag"), v.field("version"), v.field("branch")),
+			Branch:  v.field("branch"),
+			Enabled: !v.isFalse("enabled") && !v.isFalse("cond"),
+			Depends: specURLs(deps, lazyRepo),
+		})
+		if hasDeps {
+			imported = lazySpecs(deps, imported)
+		}
+	case luaRaw, luaBool:
+	}
+	return imported
+}
+
+// lazyRepo returns the repository of a single lazy.nvim spec, or "" for a
+// list of specs.
+func lazyRepo(v luaValue) string {
+	if v.kind == luaString {
+		if isRepo(v.str) {
+			return v.str
+		}
+		return ""
+	}
+	// like lazy.nvim itself, a table with more than one positional
+	// element is a list of specs rather than a spec
+	if v.kind != luaTable || len(v.list) > 1 {
+		return ""
+	}
+	if len(v.list) == 1 && v.list[0].kind == luaString && isRepo(v.list[0].str) {
+		return v.list[0].str
+	}
+	return v.field("url")
+}
+
+// specURLs returns the URLs of the specs directly in v, which is a spec or
+// a list of them, but not of the specs those depend on in turn.
+func specURLs(v luaValue, repo func(luaValue) string) []string {
+	if r := repo(v); r != "" {
+		return []string{expandRepo(r)}
+	}
+	var urls []string
+	if v.kind == luaTable {
+		for _, spec := range v.list {
+			urls = append(urls, specURLs(spec, repo)...)
+		}
+	}
+	return urls
+}
+
+// ParseLazyLock reads a lazy-lock.json file and returns the locked commit
+// of each plugin by name.
+func ParseLazyLock(r io.Reader) (map[string]string, error) {
+	lock := map[string]struct {
+		Branch string `json:"branch"`
+		Commit string `json:"commit"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&lock); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lazy-lock json: %w", err)
+	}
+	commits := make(map[string]string, len(lock))
+	for name, l := range lock {
+		commits[name] = l.Commit
+	}
+	return commits, nil
+}
+
+// LockImported pins each imported plugin that is not pinned to a commit or
+// tag to the commit a lock file, as returned by ParseLazyLock, records for
+// it. The locked commit is what was actually checked out, even of a plugin
+// that tracks a branch.
+func LockImported(imported []ImportedPlugin, commits map[string]string) {
+	for i, ip := range imported {
+		commit, ok := commits[importName(ip)]
+		if ok && commit != "" && (ip.Version == "" || ip.Version == ip.Branch) {
+			imported[i].Version = commit
+		}
+	}
+}
+
+// importName is the name an imported plugin is added under. Unlike vim-add
+// it drops the .git suffix, as the plugin managers do.
+func importName(ip ImportedPlugin) string {
+	if ip.Name != "" {
+		return ip.Name
+	}
+	return strings.TrimSuffix(nameFromURL(ip.URL), ".git")
+}
+
+// ParsePacker reads the use { ... } calls of a packer configuration.
+func ParsePacker(r io.Reader) ([]ImportedPlugin, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packer specs: %w", err)
+	}
+	imported := []ImportedPlugin{}
+	p := newLuaParser(string(src))
+	for p.peek().kind != tokEOF {
+		if !p.next().is(tokName, "use") {
+			continue
+		}
+		// use 'x', use { 'x' } and use({ 'x' }) are all the same
+		if p.peek().is(tokPunct, "(") {
+			p.next()
+		}
+		switch next := p.peek(); {
+		case next.kind == tokString:
+			p.next()
+			imported = packerSpecs(luaValue{kind: luaString, str: next.text}, imported)
+		case next.is(tokPunct, "{"):
+			p.next()
+			imported = packerSpecs(p.parseTable(), imported)
+		}
+	}
+	return imported, nil
+}
+
+func packerSpecs(v luaValue, imported []ImportedPlugin) []ImportedPlugin {
+	switch v.kind {
+	case luaString:
+		if isRepo(v.str) {
+			imported = append(imported, ImportedPlugin{URL: expandRepo(v.str), Enabled: true})
+		}
+	case luaTable:
+		if packerRepo(v) == "" {
+			for _, spec := range v.list {
+				imported = packerSpecs(spec, imported)
+			}
+			return imported
+		}
+		reqs, hasReqs := v.fields["requires"]
+		imported = append(imported, ImportedPlugin{
+			URL:     expandRepo(v.list[0].str),
+			Name:    v.field("as"),
+			Version: pinnedVersion(v.field("commit"), v.field("tag"), "", v.field("branch")),
+			Branch:  v.field("branch"),
+			Enabled: !v.isTrue("disable"),
+			Depends: specURLs(reqs, packerRepo),
+		})
+		if hasReqs {
+			imported = packerSpecs(reqs, imported)
+		}
+	case luaRaw, luaBool:
+	}
+	return imported
+}
+
+// packerRepo returns the repository of a single packer spec, or "" for a
+// list of specs.
+func packerRepo(v luaValue) string {
+	switch {
+	case v.kind == luaString && isRepo(v.str):
+		return v.str
+	case v.kind == luaTable && len(v.list) > 0 && v.list[0].kind == luaString && isRepo(v.list[0].str):
+		return v.list[0].str
+	}
+	return ""
+}
+
+var (
+	plugLine   = regexp.MustCompile(`^\s*("\s*)?Plug\s+['"]([^'"]+)['"]\s*(,\s*(\{.*\}))?`)
+	plugOption = regexp.MustCompile(`['"](\w+)['"]\s*:\s*['"]([^'"]*)['"]`)
+)
+
+// ParsePlug reads the Plug lines of a vim-plug configuration. Plug lines
+// that are commented out are imported as disabled plugins.
+func ParsePlug(r io.Reader) ([]ImportedPlugin, error) {
+	imported := []ImportedPlugin{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := plugLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		opts := map[string]string{}
+		for _, o := range plugOption.FindAllStringSubmatch(m[4], -1) {
+			opts[o[1]] = o[2]
+		}
+		imported = append(imported, ImportedPlugin{
+			URL:     expandRepo(m[2]),
+			Name:    opts["as"],
+			Version: pinnedVersion(opts["commit"], opts["tag"], "", opts["branch"]),
+			Branch:  opts["branch"],
+			Enabled: m[1] == "",
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vim-plug specs: %w", err)
+	}
+	return imported, nil
+}
+
+// Import adds the imported plugins whose URL and name are not already
+// known, recording which plugins each depends on. The added plugins are
+// returned.
+func (p Plugins) Import(imported []ImportedPlugin) []Plugin {
+	added := []Plugin{}
+	depends := [][]string{}
+	known := map[string]bool{}
+	for _, plugin := range p {
+		known[normalizeURL(plugin.URL)] = true
+	}
+	for _, ip := range imported {
+		if known[normalizeURL(ip.URL)] {
+			continue
+		}
+		known[normalizeURL(ip.URL)] = true
+		name := importName(ip)
+		if _, ok := p[name]; ok {
+			continue
+		}
+		plugin := p.Add(ip.URL, name, ip.Version)
+		if !ip.Enabled {
+			plugin = plugin.Disable()
+			p[plugin.Name] = plugin
+		}
+		added = append(added, plugin)
+		depends = append(depends, ip.Depends)
+	}
+
+	// dependencies are named once every plugin has its name
+	names := map[string]string{}
+	for name, plugin := range p {
+		names[normalizeURL(plugin.URL)] = name
+	}
+	for i, plugin := range added {
+		for _, url := range depends[i] {
+			dep, ok := names[normalizeURL(url)]
+			if ok && dep != plugin.Name && !contains(plugin.Depends, dep) {
+				plugin.Depends = append(plugin.Depends, dep)
+			}
+		}
+		added[i] = plugin
+		p[plugin.Name] = plugin
+	}
+	return added
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package tools_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestParseLazy(t *testing.T) {
+	spec := `
+-- plugins
+return {
+  'tpope/vim-fugitive',
+  { "folke/tokyonight.nvim", lazy = false, priority = 1000 },
+  {
+    'nvim-telescope/telescope.nvim',
+    tag = '0.1.5',
+    dependencies = { 'nvim-lua/plenary.nvim' },
+    keys = { { '<leader>ff', function() require('telescope.builtin').find_files() end } },
+    config = function()
+      local a, b = 1, 2
+      if a < b then require('telescope').setup({ defaults = {} }) end
+    end,
+  },
+  { 'nvim-treesitter/nvim-treesitter', branch = 'main', commit = 'abc123' },
+  { 'folke/noice.nvim', enabled = false },
+  { 'L3MON4D3/LuaSnip', version = 'v2.3.0' },
+  { 'saghen/blink.cmp', version = '1.*' },
+  { url = 'git@github.com:someone/private.nvim', name = 'private' },
+  { import = 'plugins.extra' },
+}
+`
+	got, err := tools.ParseLazy(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tools.ImportedPlugin{
+		{URL: "https://github.com/tpope/vim-fugitive", Enabled: true},
+		{URL: "https://github.com/folke/tokyonight.nvim", Enabled: true},
+		{URL: "https://github.com/nvim-telescope/telescope.nvim", Version: "0.1.5", Enabled: true, Depends: []string{"https://github.com/nvim-lua/plenary.nvim"}},
+		{URL: "https://github.com/nvim-lua/plenary.nvim", Enabled: true},
+		{URL: "https://github.com/nvim-treesitter/nvim-treesitter", Version: "abc123", Branch: "main", Enabled: true},
+		{URL: "https://github.com/folke/noice.nvim", Enabled: false},
+		{URL: "https://github.com/L3MON4D3/LuaSnip", Version: "v2.3.0", Enabled: true},
+		{URL: "https://github.com/saghen/blink.cmp", Enabled: true},
+		{URL: "git@github.com:someone/private.nvim", Name: "private", Enabled: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseLazyLock(t *testing.T) {
+	lock := `{
+  "plenary.nvim": { "branch": "master", "commit": "a3e3bc82a3f95c5ed0d7201546d5d2c19b20d683" },
+  "vim-fugitive": { "branch": "master", "commit": "0444df68cd1cdabc7453d6bd84099458327e5513" }
+}`
+	got, err := tools.ParseLazyLock(strings.NewReader(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"plenary.nvim": "a3e3bc82a3f95c5ed0d7201546d5d2c19b20d683",
+		"vim-fugitive": "0444df68cd1cdabc7453d6bd84099458327e5513",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestLockImported(t *testing.T) {
+	imported := []tools.ImportedPlugin{
+		{URL: "https://github.com/tpope/vim-fugitive.git"},
+		{URL: "https://github.com/nvim-lua/plenary.nvim", Version: "master", Branch: "master"},
+		{URL: "https://github.com/x/telescope", Name: "telescope.nvim", Version: "0.1.5"},
+		{URL: "https://github.com/x/unlocked"},
+	}
+	tools.LockImported(imported, map[string]string{
+		"vim-fugitive":   "0444df6",
+		"plenary.nvim":   "a3e3bc8",
+		"telescope.nvim": "1234567",
+	})
+	want := []string{"0444df6", "a3e3bc8", "0.1.5", ""}
+	for i, ip := range imported {
+		if ip.Version != want[i] {
+			t.Errorf("%s: got version %q, want %q", ip.URL, ip.Version, want[i])
+		}
+	}
+}
+
+func TestParsePacker(t *testing.T) {
+	spec := `
+return require('packer').startup(function(use)
+  use 'wbthomason/packer.nvim'
+  use { 'lewis6991/gitsigns.nvim', tag = 'v0.7', config = function() require('gitsigns').setup() end }
+  use({ 'nvim-telescope/telescope.nvim', requires = { { 'nvim-lua/plenary.nvim', requires = 'x/y' }, 'a/b' } })
+  use { 'dracula/vim', as = 'dracula', disable = true }
+end)
+`
+	got, err := tools.ParsePacker(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tools.ImportedPlugin{
+		{URL: "https://github.com/wbthomason/packer.nvim", Enabled: true},
+		{URL: "https://github.com/lewis6991/gitsigns.nvim", Version: "v0.7", Enabled: true},
+		{URL: "https://github.com/nvim-telescope/telescope.nvim", Enabled: true, Depends: []string{"https://github.com/nvim-lua/plenary.nvim", "https://github.com/a/b"}},
+		{URL: "https://github.com/nvim-lua/plenary.nvim", Enabled: true, Depends: []string{"https://github.com/x/y"}},
+		{URL: "https://github.com/x/y", Enabled: true},
+		{URL: "https://github.com/a/b", Enabled: true},
+		{URL: "https://github.com/dracula/vim", Name: "dracula", Enabled: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParsePlug(t *testing.T) {
+	spec := `
+call plug#begin()
+Plug 'tpope/vim-sensible'
+Plug 'junegunn/fzf', { 'tag': '0.24.0', 'do': { -> fzf#install() } }
+Plug 'https://github.com/rdnetto/YCM-Generator.git', { 'branch': 'stable' }
+" Plug 'preservim/nerdtree'
+Plug 'dracula/vim', { 'as': 'dracula' }
+call plug#end()
+`
+	got, err := tools.ParsePlug(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tools.ImportedPlugin{
+		{URL: "https://github.com/tpope/vim-sensible", Enabled: true},
+		{URL: "https://github.com/junegunn/fzf", Version: "0.24.0", Enabled: true},
+		{URL: "https://github.com/rdnetto/YCM-Generator.git", Version: "stable", Branch: "stable", Enabled: true},
+		{URL: "https://github.com/preservim/nerdtree", Enabled: false},
+		{URL: "https://github.com/dracula/vim", Name: "dracula", Enabled: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{}
+	plugins.Add("https://github.com/tpope/vim-fugitive", "", "")
+	added := plugins.Import([]tools.ImportedPlugin{
+		{URL: "https://github.com/tpope/vim-fugitive.git", Enabled: true},
+		{URL: "https://github.com/dracula/vim", Name: "dracula", Version: "v2", Enabled: false},
+		{URL: "https://github.com/other/dracula", Enabled: true},
+		{URL: "git@github.com:tpope/vim-surround.git", Enabled: true},
+	})
+	if len(added) != 2 {
+		t.Fatalf("got %d added plugins, want 2", len(added))
+	}
+	if _, ok := plugins["vim-surround"]; !ok {
+		t.Errorf("vim-surround was not added under its name without .git: %v", plugins.SortedNames())
+	}
+	// vim-add keeps naming plugins after the URL as it is
+	if added := plugins.Add("https://github.com/tpope/vim-repeat.git", "", ""); added.Name != "vim-repeat.git" {
+		t.Errorf("Add() named the plugin %s, want vim-repeat.git", added.Name)
+	}
+	delete(plugins, "vim-repeat.git")
+	want := tools.Plugin{
+		Name:       "dracula",
+		URL:        "https://github.com/dracula/vim",
+		CleanName:  "dracula",
+		ConfigFile: "dracula.lua",
+		Enabled:    false,
+		Version:    "v2",
+	}
+	if !reflect.DeepEqual(plugins["dracula"], want) {
+		t.Errorf("got %#v, want %#v", plugins["dracula"], want)
+	}
+	if len(plugins) != 3 {
+		t.Errorf("got %d plugins, want 3", len(plugins))
+	}
+}
+
+func TestImportDepends(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{}
+	plugins.Add("https://github.com/nvim-lua/plenary.nvim", "", "")
+	plugins.Import([]tools.ImportedPlugin{
+		{
+			URL:     "https://github.com/nvim-telescope/telescope.nvim",
+			Enabled: true,
+			Depends: []string{"https://github.com/nvim-lua/plenary.nvim.git", "https://github.com/x/icons", "https://github.com/x/gone"},
+		},
+		{URL: "https://github.com/x/icons", Name: "icons", Enabled: true},
+	})
+	if got, want := plugins["telescope.nvim"].Depends, []string{"plenary.nvim", "icons"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got depends %v, want %v", got, want)
+	}
+	if got := plugins["icons"].Depends; got != nil {
+		t.Errorf("got depends %v for icons, want none", got)
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AddedFormat is the layout of a plugin's added date.
+const AddedFormat = "2006-01-02"
+
+// MarkAdded records that the plugin was added at now.
+func (plugin Plugin) MarkAdded(now time.Time) Plugin {
+	plugin.Added = now.Format(AddedFormat)
+	plugin.Updated = now.Format(time.RFC3339)
+	return plugin
+}
+
+// RecordCheckout records the commit the plugin's clone has checked out.
+// The update time only changes with the commit, and not when a commit is
+// first recorded for a plugin installed before commits were recorded.
+func (plugin Plugin) RecordCheckout(now time.Time) (Plugin, error) {
+	commit, err := plugin.CurrentCommit()
+	if err != nil {
+		return plugin, err
+	}
+	if commit != plugin.Commit {
+		if plugin.Commit != "" {
+			plugin.Updated = now.Format(time.RFC3339)
+		}
+		plugin.Commit = commit
+	}
+	return plugin, nil
+}
+
+// withoutState returns the plugin without the fields that vim-add and
+// vim-check keep up to date by themselves.
+func (plugin Plugin) withoutState() Plugin {
+	plugin.Updated = ""
+	plugin.Commit = ""
+	return plugin
+}
+
+// HomepageURL returns the plugin's homepage, which for plugins hosted on
+// GitHub defaults to the repository's page.
+func (plugin Plugin) HomepageURL() string {
+	if plugin.Homepage != "" {
+		return plugin.Homepage
+	}
+	if repo := githubRepo(plugin.URL); repo != "" {
+		return "https://github.com/" + repo
+	}
+	return ""
+}
+
+// HeadInfo returns the branch checked out, or "" when HEAD is detached,
+// and the hash and commit date of HEAD.
+func (plugin *Plugin) HeadInfo() (string, string, string, error) {
+	branch, err := plugin.RunGit("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		branch = ""
+	}
+	out, err := plugin.RunGit("log", "-1", "--date=iso", "--format=%H %cd", "HEAD")
+	if err != nil {
+		return branch, "", "", err
+	}
+	f := strings.SplitN(out, " ", 2)
+	if len(f) != 2 {
+		return branch, out, "", nil
+	}
+	return branch, f[0], f[1], nil
+}
+
+// DiskUsage returns the total size of the files under dir.
+func DiskUsage(dir string) (int64, error) {
+	usage, err := RepoUsage(filepath.Base(dir), dir)
+	return usage.Total(), err
+}
+
+// HumanSize formats a size in bytes for people to read.
+func HumanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,47 @@
+package tools_test
+
+import (
+	"testing"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestPluginInfo(t *testing.T) {
+	t.Run("homepage", func(t *testing.T) {
+		tests := []struct {
+			plugin tools.Plugin
+			want   string
+		}{
+			{tools.Plugin{URL: "https://github.com/user/plugin.nvim"}, "https://github.com/user/plugin.nvim"},
+			{tools.Plugin{URL: "git@github.com:user/plugin.nvim.git"}, "https://github.com/user/plugin.nvim"},
+			{tools.Plugin{URL: "https://gitlab.com/user/plugin.nvim"}, ""},
+			{tools.Plugin{URL: "https://github.com/user/plugin.nvim", Homepage: "https://example.com"}, "https://example.com"},
+		}
+		for _, tt := range tests {
+			if got := tt.plugin.HomepageURL(); got != tt.want {
+				t.Errorf("HomepageURL() of %s = %q, want %q", tt.plugin.URL, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("added", func(t *testing.T) {
+		now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+		plugin := tools.Plugin{Name: "a"}.MarkAdded(now)
+		if plugin.Added != "2024-03-01" || plugin.Updated != "2024-03-01T12:30:00Z" {
+			t.Errorf("got %+v", plugin)
+		}
+	})
+
+	t.Run("sizes", func(t *testing.T) {
+		for size, want := range map[int64]string{
+			12:              "12 B",
+			2048:            "2.0 KiB",
+			5 * 1024 * 1024: "5.0 MiB",
+		} {
+			if got := tools.HumanSize(size); got != want {
+				t.Errorf("HumanSize(%d) = %q, want %q", size, got, want)
+			}
+		}
+	})
+}
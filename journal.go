@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JournalEntry records one command that changed the plugins.
+type JournalEntry struct {
+	ID      int            `json:"id"`
+	Time    time.Time      `json:"time"`
+	Command string         `json:"command"`
+	Args    []string       `json:"args,omitempty"`
+	Changes []PluginChange `json:"changes,omitempty"`
+	Notes   []string       `json:"notes,omitempty"`  // what the command did besides changing plugins
+	Undoes  []int          `json:"undoes,omitempty"` // the entries this one undid
+}
+
+// CommandLine returns the command that made the entry, quoting arguments
+// where the shell would need them to be.
+func (entry JournalEntry) CommandLine() string {
+	words := []string{entry.Command}
+	for _, arg := range entry.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// PluginChange is a plugin before and after a command. Before is nil for
+// a plugin that was added and After for one that was removed.
+type PluginChange struct {
+	Name   string  `json:"name"`
+	Before *Plugin `json:"before,omitempty"`
+	After  *Plugin `json:"after,omitempty"`
+}
+
+// FieldChange is a single field of a plugin that a command changed.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// JournalPath ....
+func JournalPath() string {
+	return filepath.Join(MetadataDir(), "journal.jsonl")
+}
+
+// DiffPlugins returns the plugins that differ between before and after,
+// sorted by name.
+func DiffPlugins(before, after Plugins) []PluginChange {
+	changes := []PluginChange{}
+	for name, b := range before {
+		b := b
+		if a, ok := after[name]; !ok {
+			changes = append(changes, PluginChange{Name: name, Before: &b})
+		} else if !reflect.DeepEqual(a, b) {
+			changes = append(changes, PluginChange{Name: name, Before: &b, After: &a})
+		}
+	}
+	for name, a := range after {
+		a := a
+		if _, ok := before[name]; !ok {
+			changes = append(changes, PluginChange{Name: name, After: &a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// Fields returns the fields of a changed plugin that differ, using their
+// names in the plugins file.
+func (c PluginChange) Fields() []FieldChange {
+	before, after := pluginFields(c.Before), pluginFields(c.After)
+	names := []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fields := []FieldChange{}
+	for _, name := range names {
+		if before[name] != after[name] {
+			fields = append(fields, FieldChange{Field: name, Before: before[name], After: after[name]})
+		}
+	}
+	return fields
+}
+
+// pluginFields returns the fields of a plugin as they appear in JSON.
+func pluginFields(plugin *Plugin) map[string]string {
+	fields := map[string]string{}
+	if plugin == nil {
+		return fields
+	}
+	data, err := json.Marshal(plugin)
+	if err != nil {
+		return fields
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fields
+	}
+	for name, value := range raw {
+		fields[name] = string(value)
+	}
+	return fields
+}
+
+// ReadJournal returns every entry in the journal, oldest first. A missing
+// journal is empty.
+func ReadJournal() ([]JournalEntry, error) {
+	entries := []JournalEntry{}
+	f, err := Filesys.Open(JournalPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := JournalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal journal entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// AppendJournal adds an entry to the journal, numbering it after the last
+// one. The caller must hold the lock.
+func AppendJournal(entry JournalEntry) (JournalEntry, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return entry, err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("conversion to JSON failed: %w", err)
+	}
+	f, err := Filesys.OpenFile(JournalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return entry, fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return entry, nil
+}
+
+// changesSettings reports whether an entry changed more than the fields
+// that are kept up to date automatically, such as the commit checked out.
+func (entry JournalEntry) changesSettings() bool {
+	for _, c := range entry.Changes {
+		if c.Before == nil || c.After == nil || !reflect.DeepEqual(c.Before.withoutState(), c.After.withoutState()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Undoable returns the most recent n entries that changed plugins and have
+// not been undone, most recent first. Entries made by undoing, and those
+// that only changed what is kept up to date automatically, are skipped.
+func Undoable(entries []JournalEntry, n int) []JournalEntry {
+	undone := map[int]bool{}
+	for _, entry := range entries {
+		for _, id := range entry.Undoes {
+			undone[id] = true
+		}
+	}
+	found := []JournalEntry{}
+	for i := len(entries) - 1; i >= 0 && len(found) < n; i-- {
+		entry := entries[i]
+		if entry.changesSettings() && len(entry.Undoes) == 0 && !undone[entry.ID] {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// Revert puts the plugins changed by an entry back as they were, keeping
+// what is kept up to date automatically as it is now. Unless forced it
+// refuses to when a plugin has changed since. The renames the entry made
+// are returned as a map from the new name to the old, so that the caller
+// can move the plugin's files back.
+func (p Plugins) Revert(entry JournalEntry, force bool) (map[string]string, error) {
+	if !force {
+		for _, c := range entry.Changes {
+			current, ok := p[c.Name]
+			if (c.After == nil && ok) || (c.After != nil && (!ok || !reflect.DeepEqual(current.withoutState(), c.After.withoutState()))) {
+				return nil, fmt.Errorf("plugin %s has changed since entry %d", c.Name, entry.ID)
+			}
+		}
+	}
+	renames := map[string]string{}
+	for _, added := range entry.Changes {
+		if added.Before != nil || added.After == nil {
+			continue
+		}
+		for _, removed := range entry.Changes {
+			if removed.After == nil && removed.Before != nil && removed.Before.URL == added.After.URL {
+				renames[added.Name] = removed.Name
+			}
+		}
+	}
+	// a renamed plugin keeps the state of its clone
+	state := map[string]Plugin{}
+	for _, c := range entry.Changes {
+		if current, ok := p[c.Name]; ok {
+			state[c.Name] = current
+		}
+	}
+	for newName, oldName := range renames {
+		if current, ok := state[newName]; ok {
+			state[oldName] = current
+		}
+	}
+	for _, c := range entry.Changes {
+		current, ok := state[c.Name]
+		switch {
+		case c.Before == nil:
+			delete(p, c.Name)
+		case ok:
+			plugin := *c.Before
+			plugin.Updated, plugin.Commit = current.Updated, current.Commit
+			p[c.Name] = plugin
+		default:
+			p[c.Name] = *c.Before
+		}
+	}
+	return renames, nil
+}
+
+// Unrename moves the directory and config file of a plugin that entry
+// renamed from oldName to newName back.
+func (tx *Tx) Unrename(entry JournalEntry, newName, oldName string) error {
+	var before, after *Plugin
+	for _, c := range entry.Changes {
+		switch c.Name {
+		case oldName:
+			before = c.Before
+		case newName:
+			after = c.After
+		}
+	}
+	if before == nil || after == nil {
+		return fmt.Errorf("entry %d does not rename %s to %s", entry.ID, oldName, newName)
+	}
+	if _, err := Filesys.Stat(after.Dir()); err == nil {
+		if err := tx.Rename(after.Dir(), before.Dir()); err != nil {
+			return err
+		}
+	}
+	if _, err := Filesys.Stat(after.ConfigFilePath()); err == nil && after.ConfigFilePath() != before.ConfigFilePath() {
+		if err := tx.Rename(after.ConfigFilePath(), before.ConfigFilePath()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
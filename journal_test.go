@@ -0,0 +1,170 @@
+package tools_test
+
+import (
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestJournal(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{
+		"a": {Name: "a", URL: "https://github.com/user/a", Enabled: true},
+		"b": {Name: "b", URL: "https://github.com/user/b", Enabled: true},
+	}
+	if err := plugins.Write(); err != nil {
+		t.Fatal(err)
+	}
+	commit := func(t *testing.T, change func(tools.Plugins)) {
+		t.Helper()
+		tx := tools.Begin(plugins)
+		change(plugins)
+		if err := tx.Commit(plugins); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit(t, func(p tools.Plugins) { p["a"] = p["a"].Disable() })
+	commit(t, func(p tools.Plugins) {}) // nothing changed, nothing journaled
+	commit(t, func(p tools.Plugins) {
+		p["c"] = tools.Plugin{Name: "c", URL: p["b"].URL, Enabled: true}
+		delete(p, "b")
+	})
+
+	entries, err := tools.ReadJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != 1 || entries[1].ID != 2 {
+		t.Fatalf("got %+v, want two entries", entries)
+	}
+
+	t.Run("changed fields", func(t *testing.T) {
+		want := []tools.FieldChange{{Field: "enabled", Before: "true", After: "false"}}
+		if got := entries[0].Changes[0].Fields(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		undoable := tools.Undoable(entries, 5)
+		if len(undoable) != 2 || undoable[0].ID != 2 {
+			t.Fatalf("got %+v", undoable)
+		}
+		renames, err := plugins.Revert(undoable[0], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(renames, map[string]string{"c": "b"}) {
+			t.Errorf("got renames %v", renames)
+		}
+		if _, ok := plugins["b"]; !ok {
+			t.Error("b not restored")
+		}
+
+		tx := tools.Begin(plugins)
+		tx.MarkUndo([]int{2})
+		plugins["b"] = plugins["b"].Disable()
+		if err := tx.Commit(plugins); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := tools.ReadJournal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		undoable = tools.Undoable(entries, 5)
+		if len(undoable) != 1 || undoable[0].ID != 1 {
+			t.Fatalf("got %+v, want only entry 1", undoable)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		plugins["a"] = plugins["a"].Freeze("v1.0")
+		if _, err := plugins.Revert(entries[0], false); err == nil {
+			t.Error("expected a conflict")
+		}
+		if _, err := plugins.Revert(entries[0], true); err != nil {
+			t.Fatal(err)
+		}
+		if !plugins["a"].IsEnabled() || plugins["a"].HasVersion() {
+			t.Errorf("got %+v", plugins["a"])
+		}
+	})
+}
+
+func TestUndoKeepsCheckouts(t *testing.T) {
+	before := tools.Plugin{Name: "a", Enabled: true, Commit: "abc"}
+	checked := before
+	checked.Commit, checked.Updated = "def", "2024-03-01T12:30:00Z"
+	disabled := checked.Disable()
+	entries := []tools.JournalEntry{
+		{ID: 1, Changes: []tools.PluginChange{{Name: "a", Before: &before, After: &checked}}},
+		{ID: 2, Changes: []tools.PluginChange{{Name: "a", Before: &checked, After: &disabled}}},
+	}
+
+	undoable := tools.Undoable(entries, 2)
+	if len(undoable) != 1 || undoable[0].ID != 2 {
+		t.Fatalf("got %+v, want only entry 2", undoable)
+	}
+
+	// checked again since it was disabled
+	current := disabled
+	current.Commit = "fff"
+	plugins := tools.Plugins{"a": current}
+	if _, err := plugins.Revert(undoable[0], false); err != nil {
+		t.Fatal(err)
+	}
+	if !plugins["a"].IsEnabled() || plugins["a"].Commit != "fff" {
+		t.Errorf("got %+v, want enabled with the current commit", plugins["a"])
+	}
+}
+
+func TestUnrename(t *testing.T) {
+	prepareEnv(t)
+
+	before := tools.Plugin{Name: "old", CleanName: "old", ConfigFile: "old.lua", URL: "https://github.com/user/plugin"}
+	after := tools.Plugin{Name: "new", CleanName: "new", ConfigFile: "new.lua", URL: before.URL}
+	entry := tools.JournalEntry{ID: 3, Changes: []tools.PluginChange{
+		{Name: "new", After: &after},
+		{Name: "old", Before: &before},
+	}}
+	if err := tools.Filesys.MkdirAll(after.Dir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(tools.Filesys, after.ConfigFilePath(), []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := tools.Begin(tools.Plugins{})
+	if err := tx.Unrename(entry, "new", "old"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{before.Dir(), before.ConfigFilePath()} {
+		if _, err := tools.Filesys.Stat(path); err != nil {
+			t.Errorf("%s was not moved back: %s", path, err)
+		}
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tools.Filesys.Stat(after.ConfigFilePath()); err != nil {
+		t.Errorf("rollback did not move %s again: %s", after.ConfigFilePath(), err)
+	}
+
+	// a hand-edited entry may lack either side of the rename
+	entry.Changes = entry.Changes[:1]
+	if err := tools.Begin(tools.Plugins{}).Unrename(entry, "new", "old"); err == nil {
+		t.Error("expected an error for an entry without the old plugin")
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	entry := tools.JournalEntry{Command: "vim-info", Args: []string{"-notes", "it's broken", "", "alpha"}}
+	want := `vim-info -notes 'it'\''s broken' '' alpha`
+	if got := entry.CommandLine(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LocalPolicy decides what happens to local modifications when a plugin is
+// updated.
+type LocalPolicy string
+
+// Local modification policies.
+const (
+	LocalSkip  LocalPolicy = "skip"
+	LocalStash LocalPolicy = "stash"
+	LocalForce LocalPolicy = "force"
+)
+
+// ErrLocalChanges is returned when an update is skipped to protect local
+// modifications.
+var ErrLocalChanges = errors.New("plugin has local modifications")
+
+// ParseLocalPolicy ....
+func ParseLocalPolicy(s string) (LocalPolicy, error) {
+	switch p := LocalPolicy(s); p {
+	case LocalSkip, LocalStash, LocalForce:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown local modification policy %q", s)
+}
+
+// LocalChanges ....
+type LocalChanges struct {
+	Modified []string // tracked files with uncommitted changes
+	Commits  []string // commits not found on any remote branch or tag
+}
+
+// IsEmpty ....
+func (lc LocalChanges) IsEmpty() bool {
+	return len(lc.Modified) == 0 && len(lc.Commits) == 0
+}
+
+// LocalChanges returns the uncommitted changes and the local commits of a
+// plugin. Commits are compared against the remote-tracking branches, so
+// these should be fetched first for an accurate answer.
+func (plugin *Plugin) LocalChanges() (LocalChanges, error) {
+	changes := LocalChanges{}
+	status, err := plugin.RunGit("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return changes, err
+	}
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) > 3 {
+			changes.Modified = append(changes.Modified, line[3:])
+		}
+	}
+	commits, err := plugin.RunGit(plugin.localCommitsArgs("log", "--format=%h %s")...)
+	if err != nil {
+		return changes, err
+	}
+	for _, line := range strings.Split(commits, "\n") {
+		if line != "" {
+			changes.Commits = append(changes.Commits, line)
+		}
+	}
+	return changes, nil
+}
+
+// LocalDiff returns the uncommitted changes and the patches of the local
+// commits of a plugin.
+func (plugin *Plugin) LocalDiff() (string, string, error) {
+	diff, err := plugin.RunGit("diff", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	patches, err := plugin.RunGit(plugin.localCommitsArgs("log", "--patch")...)
+	if err != nil {
+		return "", "", err
+	}
+	return diff, patches, nil
+}
+
+func (plugin *Plugin) localCommitsArgs(args ...string) []string {
+	return append(args, "HEAD", "--not", "--remotes=origin", "--tags")
+}
+
+// DiscardLocalChanges throws away uncommitted changes and, unless the
+// plugin is frozen, local commits on its branch, resetting it to the
+// fetched remote branch.
+func (plugin *Plugin) DiscardLocalChanges() error {
+	if _, err := plugin.RunGit("reset", "--quiet", "--hard"); err != nil {
+		return err
+	}
+	if plugin.HasVersion() {
+		// the update resets a frozen plugin to its version
+		return nil
+	}
+	branch, err := plugin.RunGit("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		// detached, so there is no branch to reset
+		return nil
+	}
+	remote := "refs/remotes/origin/" + branch
+	if _, err := plugin.RunGit("rev-parse", "--verify", "--quiet", remote); err != nil {
+		// the branch has gone upstream and the update will switch away from it
+		return nil
+	}
+	_, err = plugin.RunGit("reset", "--quiet", "--hard", remote)
+	return err
+}
+
+// ProtectLocalChanges runs update, first deciding according to policy what
+// to do with any local modifications. When the update is skipped an error
+// wrapping ErrLocalChanges is returned.
+func (plugin *Plugin) ProtectLocalChanges(policy LocalPolicy, update func() error) error {
+	if err := plugin.Fetch(); err != nil {
+		return err
+	}
+	if policy == LocalForce {
+		if err := plugin.DiscardLocalChanges(); err != nil {
+			return fmt.Errorf("%s: failed to discard local changes: %w", plugin.Name, err)
+		}
+		return update()
+	}
+	changes, err := plugin.LocalChanges()
+	if err != nil {
+		return err
+	}
+	if changes.IsEmpty() {
+		return update()
+	}
+	// a frozen plugin is reset to its version, so local commits cannot be kept
+	if policy == LocalSkip || (len(changes.Commits) > 0 && plugin.HasVersion()) {
+		return fmt.Errorf(
+			"%s: %w (%d modified files, %d local commits)",
+			plugin.Name, ErrLocalChanges, len(changes.Modified), len(changes.Commits),
+		)
+	}
+
+	stashed := false
+	if len(changes.Modified) > 0 {
+		if _, err := plugin.RunGit("stash", "push", "--quiet", "--message", "vim-tools update"); err != nil {
+			return err
+		}
+		stashed = true
+	}
+	updateErr := update()
+	if stashed {
+		if _, err := plugin.RunGit("stash", "pop", "--quiet"); err != nil {
+			return fmt.Errorf("%s: failed to reapply local changes, they are kept in the stash: %w", plugin.Name, err)
+		}
+	}
+	return updateErr
+}
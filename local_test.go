@@ -0,0 +1,109 @@
+package tools_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestProtectLocalChanges(t *testing.T) {
+	// a plugin with an uncommitted change and a local commit, and an
+	// upstream one commit ahead
+	setup := func(t *testing.T) (tools.Plugin, string) {
+		prepareGitEnv(t)
+		upstream := upstreamRepo(t, 1)
+		plugin := clonePlugin(t, upstream, "plugin")
+		commitFile(t, plugin.Dir(), "local.txt", "committed")
+		if err := os.WriteFile(filepath.Join(plugin.Dir(), "other.txt"), []byte("modified"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		head := commitFile(t, upstream, "file.txt", "2")
+		return plugin, head
+	}
+	pull := func(plugin tools.Plugin) func() error {
+		return func() error {
+			_, err := plugin.RunGit("pull", "--quiet", "--rebase", plugin.URL, "main")
+			return err
+		}
+	}
+	content := func(t *testing.T, plugin tools.Plugin, file string) string {
+		data, err := os.ReadFile(filepath.Join(plugin.Dir(), file))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		plugin, _ := setup(t)
+		before := git(t, plugin.Dir(), "rev-parse", "HEAD")
+		err := plugin.ProtectLocalChanges(tools.LocalSkip, pull(plugin))
+		if !errors.Is(err, tools.ErrLocalChanges) {
+			t.Fatalf("got %v, want ErrLocalChanges", err)
+		}
+		if got := git(t, plugin.Dir(), "rev-parse", "HEAD"); got != before {
+			t.Errorf("HEAD moved from %s to %s", before, got)
+		}
+		if got := content(t, plugin, "other.txt"); got != "modified" {
+			t.Errorf("uncommitted change lost, other.txt is %q", got)
+		}
+	})
+
+	t.Run("stash", func(t *testing.T) {
+		plugin, head := setup(t)
+		if err := plugin.ProtectLocalChanges(tools.LocalStash, pull(plugin)); err != nil {
+			t.Fatal(err)
+		}
+		if got := git(t, plugin.Dir(), "rev-parse", "HEAD~1"); got != head {
+			t.Errorf("local commit not rebased onto %s, HEAD~1 is %s", head, got)
+		}
+		if got := content(t, plugin, "other.txt"); got != "modified" {
+			t.Errorf("uncommitted change lost, other.txt is %q", got)
+		}
+		if got := content(t, plugin, "file.txt"); got != "2" {
+			t.Errorf("not updated, file.txt is %q", got)
+		}
+	})
+
+	t.Run("force", func(t *testing.T) {
+		plugin, head := setup(t)
+		if err := plugin.ProtectLocalChanges(tools.LocalForce, pull(plugin)); err != nil {
+			t.Fatal(err)
+		}
+		if got := git(t, plugin.Dir(), "rev-parse", "HEAD"); got != head {
+			t.Errorf("HEAD is %s, want upstream %s", got, head)
+		}
+		if got := content(t, plugin, "other.txt"); got != "other" {
+			t.Errorf("uncommitted change kept, other.txt is %q", got)
+		}
+		if got := content(t, plugin, "local.txt"); got != "" {
+			t.Errorf("local commit kept, local.txt is %q", got)
+		}
+	})
+}
+
+func TestParseLocalPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    tools.LocalPolicy
+		wantErr bool
+	}{
+		{"skip", tools.LocalSkip, false},
+		{"stash", tools.LocalStash, false},
+		{"force", tools.LocalForce, false},
+		{"", "", true},
+		{"merge", "", true},
+	}
+	for _, tt := range tests {
+		got, err := tools.ParseLocalPolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLocalPolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLocalPolicy(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
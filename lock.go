@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when another vim-tools process holds the lock.
+var ErrLocked = errors.New("another vim-tools process is running")
+
+// LockWaitEnv names the environment variable that, like lock_wait in the
+// config file, says how long to wait for the lock.
+const LockWaitEnv = "VIM_TOOLS_LOCK_WAIT"
+
+// LockWait says how long LockPlugins waits for the lock, overriding
+// $VIM_TOOLS_LOCK_WAIT and the config file when set; see LockWaitFlag.
+var LockWait string
+
+// LockWaitFlag adds the -wait flag, which sets LockWait, to the command
+// line flags.
+func LockWaitFlag() {
+	flag.StringVar(&LockWait, "wait", "", "How long to wait for another vim-tools process, e.g. 30s or forever (default: $"+LockWaitEnv+" or lock_wait from config.json)")
+}
+
+// lockPoll is how often a held lock is tried again.
+const lockPoll = 100 * time.Millisecond
+
+// Lock is an advisory lock on the plugins. It is released when the
+// process exits, even if Release is never called.
+type Lock struct {
+	f *os.File
+}
+
+// LockPath ....
+func LockPath() string {
+	return filepath.Join(MetadataDir(), "lock")
+}
+
+// LockPlugins takes the lock that must be held for every change to the
+// plugins, waiting for it as long as -wait, $VIM_TOOLS_LOCK_WAIT or
+// lock_wait in the config file allow.
+func LockPlugins() (*Lock, error) {
+	wait := LockWait
+	if wait == "" {
+		wait = os.Getenv(LockWaitEnv)
+	}
+	if wait == "" {
+		config, err := ReadConfig()
+		if err != nil {
+			return nil, err
+		}
+		wait = config.LockWait
+	}
+	timeout, err := ParseLockWait(wait)
+	if err != nil {
+		return nil, err
+	}
+	return AcquireLock(timeout)
+}
+
+// ParseLockWait reads how long to wait for the lock: a duration such as
+// 30s, "forever", or "" for not at all.
+func ParseLockWait(wait string) (time.Duration, error) {
+	switch wait {
+	case "":
+		return 0, nil
+	case "forever":
+		return -1, nil
+	}
+	d, err := time.ParseDuration(wait)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid lock wait %q, use a duration such as 30s or forever", wait)
+	}
+	return d, nil
+}
+
+// AcquireLock takes the lock, waiting up to timeout for another process
+// to release it. A negative timeout waits for as long as it takes.
+func AcquireLock(timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(MetadataDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", MetadataDir(), err)
+	}
+	f, err := os.OpenFile(LockPath(), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", LockPath(), err)
+		}
+		if locked {
+			break
+		}
+		if timeout >= 0 && !time.Now().Before(deadline) {
+			pid := lockHolder(f)
+			f.Close()
+			if pid != "" {
+				return nil, fmt.Errorf("%w (pid %s), use -wait or set %s to wait for it", ErrLocked, pid, LockWaitEnv)
+			}
+			return nil, fmt.Errorf("%w, use -wait or set %s to wait for it", ErrLocked, LockWaitEnv)
+		}
+		time.Sleep(lockPoll)
+	}
+	// the pid is only informative, so failing to record it does not matter
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// lockHolder returns the pid recorded by the process holding the lock.
+func lockHolder(f *os.File) string {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	return strings.TrimSpace(string(buf[:n]))
+}
+
+// Release ....
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlock(l.f)
+	l.f.Close()
+	l.f = nil
+	return err
+}
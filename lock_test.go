@@ -0,0 +1,75 @@
+//go:build !windows
+// +build !windows
+
+package tools_test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestLock(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	lock, err := tools.AcquireLock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("held", func(t *testing.T) {
+		_, err := tools.AcquireLock(0)
+		if !errors.Is(err, tools.ErrLocked) {
+			t.Fatalf("got %v, want ErrLocked", err)
+		}
+		if !strings.Contains(err.Error(), "pid "+strconv.Itoa(os.Getpid())) {
+			t.Errorf("pid missing from %q", err)
+		}
+	})
+
+	t.Run("wait flag", func(t *testing.T) {
+		t.Setenv(tools.LockWaitEnv, "soon")
+		tools.LockWait = "10ms"
+		defer func() { tools.LockWait = "" }()
+		if _, err := tools.LockPlugins(); !errors.Is(err, tools.ErrLocked) {
+			t.Fatalf("got %v, want ErrLocked after waiting as long as -wait says", err)
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			lock.Release()
+		}()
+		lock2, err := tools.AcquireLock(5 * time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lock2.Release()
+	})
+}
+
+func TestParseLockWait(t *testing.T) {
+	tests := []struct {
+		wait string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, true},
+		{"30s", 30 * time.Second, true},
+		{"forever", -1, true},
+		{"-5s", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := tools.ParseLockWait(tt.wait)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseLockWait(%q) = %v, %v", tt.wait, got, err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package tools
+
+import (
+	"errors"
+	"os"
+)
+
+// Windows has no flock, and rather than pretend to lock the plugins every
+// command that changes them refuses to run.
+var errNoLock = errors.New("locking the plugins is not supported on Windows")
+
+func tryLock(f *os.File) (bool, error) {
+	return false, errNoLock
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The plugin managers that can be imported from describe their plugins
+// with Lua table constructors. Rather than embed a Lua interpreter, the
+// few parts of Lua needed to read those tables are understood here:
+// strings, booleans and (nested) tables. Any other expression, such as a
+// function, is skipped and kept as raw text.
+
+type luaKind int
+
+const (
+	luaRaw luaKind = iota
+	luaString
+	luaBool
+	luaTable
+)
+
+type luaValue struct {
+	kind   luaKind
+	str    string
+	b      bool
+	list   []luaValue
+	fields map[string]luaValue
+}
+
+// field returns the named string field of a table, or "".
+func (v luaValue) field(name string) string {
+	if f, ok := v.fields[name]; ok && f.kind == luaString {
+		return f.str
+	}
+	return ""
+}
+
+// isFalse reports whether the named field of a table is literally false.
+func (v luaValue) isFalse(name string) bool {
+	f, ok := v.fields[name]
+	return ok && f.kind == luaBool && !f.b
+}
+
+// isTrue reports whether the named field of a table is literally true.
+func (v luaValue) isTrue(name string) bool {
+	f, ok := v.fields[name]
+	return ok && f.kind == luaBool && f.b
+}
+
+type luaTokenKind int
+
+const (
+	tokEOF luaTokenKind = iota
+	tokString
+	tokName
+	tokNumber
+	tokPunct
+)
+
+type luaToken struct {
+	kind luaTokenKind
+	text string
+}
+
+func (t luaToken) is(kind luaTokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// longBracket returns the length of the opening long bracket ([[, [=[, ...)
+// at the start of s and its level, or 0 if there is none.
+func longBracket(s string) (int, int) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, 0
+	}
+	level := 0
+	for level+1 < len(s) && s[level+1] == '=' {
+		level++
+	}
+	if level+1 < len(s) && s[level+1] == '[' {
+		return level + 2, level
+	}
+	return 0, 0
+}
+
+// luaLex splits Lua source into tokens, dropping whitespace and comments.
+func luaLex(src string) []luaToken {
+	toks := []luaToken{}
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			if n, level := longBracket(src[i:]); n > 0 {
+				end := strings.Index(src[i+n:], "]"+strings.Repeat("=", level)+"]")
+				if end < 0 {
+					return toks
+				}
+				i += n + end + level + 2
+				continue
+			}
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			var sb strings.Builder
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[i])
+					}
+				} else {
+					sb.WriteByte(src[i])
+				}
+				i++
+			}
+			i++
+			toks = append(toks, luaToken{tokString, sb.String()})
+		case c == '[':
+			n, level := longBracket(src[i:])
+			if n == 0 {
+				toks = append(toks, luaToken{tokPunct, "["})
+				i++
+				continue
+			}
+			closing := "]" + strings.Repeat("=", level) + "]"
+			end := strings.Index(src[i+n:], closing)
+			if end < 0 {
+				toks = append(toks, luaToken{tokString, src[i+n:]})
+				return toks
+			}
+			toks = append(toks, luaToken{tokString, strings.TrimPrefix(src[i+n:i+n+end], "\n")})
+			i += n + end + len(closing)
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			toks = append(toks, luaToken{tokName, src[start:i]})
+		case unicode.IsDigit(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '.' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			toks = append(toks, luaToken{tokNumber, src[start:i]})
+		default:
+			n := 1
+			if i+1 < len(src) && src[i+1] == '=' && strings.ContainsRune("=~<>", rune(c)) {
+				n = 2
+			}
+			toks = append(toks, luaToken{tokPunct, src[i : i+n]})
+			i += n
+		}
+	}
+	return toks
+}
+
+type luaParser struct {
+	toks []luaToken
+	pos  int
+}
+
+func newLuaParser(src string) *luaParser {
+	return &luaParser{toks: luaLex(src)}
+}
+
+func (p *luaParser) peekAt(n int) luaToken {
+	if p.pos+n >= len(p.toks) {
+		return luaToken{kind: tokEOF}
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *luaParser) peek() luaToken {
+	return p.peekAt(0)
+}
+
+func (p *luaParser) next() luaToken {
+	t := p.peek()
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// endsValue reports whether t ends a value inside a table or call.
+func endsValue(t luaToken) bool {
+	if t.kind == tokEOF {
+		return true
+	}
+	if t.kind != tokPunct {
+		return false
+	}
+	switch t.text {
+	case ",", ";", "}", ")", "]":
+		return true
+	}
+	return false
+}
+
+// skipExpr consumes tokens up to the end of the current value, keeping
+// track of brackets and of blocks such as function ... end.
+func (p *luaParser) skipExpr() {
+	depth := 0
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || (depth == 0 && endsValue(t)) {
+			return
+		}
+		switch {
+		case t.kind == tokPunct && strings.Contains("({[", t.text):
+			depth++
+		case t.kind == tokPunct && strings.Contains(")}]", t.text):
+			depth--
+		case t.kind == tokName:
+			switch t.text {
+			case "function", "if", "do", "repeat":
+				depth++
+			case "end", "until":
+				depth--
+			}
+		}
+		p.next()
+	}
+}
+
+func (p *luaParser) parseValue() luaValue {
+	start := p.pos
+	t := p.peek()
+	var v luaValue
+	switch {
+	case t.is(tokPunct, "{"):
+		p.next()
+		return p.parseTable()
+	case t.kind == tokString:
+		p.next()
+		v = luaValue{kind: luaString, str: t.text}
+	case t.is(tokName, "true"), t.is(tokName, "false"):
+		p.next()
+		v = luaValue{kind: luaBool, b: t.text == "true"}
+	}
+	if v.kind != luaRaw && endsValue(p.peek()) {
+		return v
+	}
+	// anything else, including 'a' .. 'b', is an expression we do not evaluate
+	p.skipExpr()
+	if p.pos == start {
+		p.next()
+	}
+	raw := []string{}
+	for _, tok := range p.toks[start:p.pos] {
+		raw = append(raw, tok.text)
+	}
+	return luaValue{kind: luaRaw, str: strings.Join(raw, " ")}
+}
+
+// parseTable parses the rest of a table constructor after its opening brace.
+func (p *luaParser) parseTable() luaValue {
+	tbl := luaValue{kind: luaTable, fields: map[string]luaValue{}}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return tbl
+		case t.is(tokPunct, "}"):
+			p.next()
+			return tbl
+		case t.is(tokPunct, ",") || t.is(tokPunct, ";"):
+			p.next()
+		case t.kind == tokName && p.peekAt(1).is(tokPunct, "="):
+			p.next()
+			p.next()
+			tbl.fields[t.text] = p.parseValue()
+		case t.is(tokPunct, "["):
+			// [expr] = value
+			p.next()
+			key := p.parseValue()
+			if p.peek().is(tokPunct, "]") {
+				p.next()
+			}
+			if p.peek().is(tokPunct, "=") {
+				p.next()
+			}
+			value := p.parseValue()
+			if key.kind == luaString {
+				tbl.fields[key.str] = value
+			}
+		default:
+			tbl.list = append(tbl.list, p.parseValue())
+		}
+	}
+}
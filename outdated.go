@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PinStatus ....
+type PinStatus struct {
+	Version    string
+	Commit     string
+	CommitDate string
+	LatestTag  string
+	Head       string
+	HeadDate   string
+	Behind     int
+}
+
+// BehindUnknown is PinStatus.Behind when the number of commits cannot be
+// counted, because a shallow clone lacks the history between the two.
+const BehindUnknown = -1
+
+// IsOutdated ....
+func (ps PinStatus) IsOutdated() bool {
+	return ps.Behind > 0 || (ps.Behind == BehindUnknown && ps.Commit != ps.Head)
+}
+
+// LatestRemoteTag ....
+func (plugin *Plugin) LatestRemoteTag() (string, error) {
+	out, err := plugin.RunGit("ls-remote", "--tags", "--refs", "--sort=-v:refname", plugin.URL)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 2 {
+			return strings.TrimPrefix(f[1], "refs/tags/"), nil
+		}
+	}
+	return "", nil
+}
+
+// PinStatus compares the version a plugin is frozen to with the remote's
+// default branch. The remote is fetched as a side effect.
+func (plugin *Plugin) PinStatus() (PinStatus, error) {
+	status := PinStatus{Version: plugin.Version}
+	if !plugin.HasVersion() {
+		return status, fmt.Errorf("%s: plugin is not frozen", plugin.Name)
+	}
+
+	if _, err := plugin.RunGit("fetch", "--quiet", "--tags", plugin.URL, "HEAD"); err != nil {
+		return status, err
+	}
+	commit, err := plugin.ResolveRef(plugin.Version)
+	if err != nil {
+		return status, err
+	}
+	status.Commit, status.CommitDate, err = plugin.commitInfo(commit)
+	if err != nil {
+		return status, err
+	}
+	status.Head, status.HeadDate, err = plugin.commitInfo("FETCH_HEAD")
+	if err != nil {
+		return status, err
+	}
+	shallow, err := plugin.IsShallow()
+	if err != nil {
+		return status, err
+	}
+	if shallow && status.Commit != status.Head {
+		// the count would stop at the shallow boundary
+		status.Behind = BehindUnknown
+	} else {
+		behind, err := plugin.RunGit("rev-list", "--count", commit+"..FETCH_HEAD")
+		if err != nil {
+			return status, err
+		}
+		if status.Behind, err = strconv.Atoi(behind); err != nil {
+			return status, fmt.Errorf("%s: unexpected rev-list output %q: %w", plugin.Name, behind, err)
+		}
+	}
+	status.LatestTag, err = plugin.LatestRemoteTag()
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// commitInfo returns the abbreviated hash and commit date of rev.
+func (plugin *Plugin) commitInfo(rev string) (string, string, error) {
+	out, err := plugin.RunGit("log", "-1", "--date=short", "--format=%h %cd", rev)
+	if err != nil {
+		return "", "", err
+	}
+	f := strings.Fields(out)
+	if len(f) != 2 {
+		return "", "", fmt.Errorf("%s: unexpected log output %q", plugin.Name, out)
+	}
+	return f[0], f[1], nil
+}
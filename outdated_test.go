@@ -0,0 +1,79 @@
+package tools_test
+
+import (
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestPinStatus(t *testing.T) {
+	prepareGitEnv(t)
+	upstream := upstreamRepo(t, 3)
+	short := func(rev string) string {
+		return git(t, upstream, "rev-parse", "--short", rev)
+	}
+
+	t.Run("behind", func(t *testing.T) {
+		plugin := clonePlugin(t, upstream, "behind")
+		plugin.Version = "v0.1"
+		git(t, plugin.Dir(), "checkout", "--quiet", "v0.1")
+		status, err := plugin.PinStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Commit != short("v0.1") || status.Head != short("main") {
+			t.Errorf("got commit %s and head %s, want %s and %s", status.Commit, status.Head, short("v0.1"), short("main"))
+		}
+		if status.Behind != 2 || !status.IsOutdated() || status.LatestTag != "v0.3" {
+			t.Errorf("got %+v, want 2 behind v0.3", status)
+		}
+	})
+
+	t.Run("current", func(t *testing.T) {
+		plugin := clonePlugin(t, upstream, "current")
+		plugin.Version = "v0.3"
+		status, err := plugin.PinStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Behind != 0 || status.IsOutdated() {
+			t.Errorf("got %+v, want up to date", status)
+		}
+	})
+
+	t.Run("shallow", func(t *testing.T) {
+		plugin := clonePlugin(t, "file://"+upstream, "shallow", "--depth=1", "--branch=v0.1")
+		plugin.Version = "v0.1"
+		status, err := plugin.PinStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Behind != tools.BehindUnknown || !status.IsOutdated() {
+			t.Errorf("got %+v, want an unknown number behind", status)
+		}
+	})
+
+	t.Run("not frozen", func(t *testing.T) {
+		plugin := clonePlugin(t, upstream, "unfrozen")
+		if _, err := plugin.PinStatus(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestLatestRemoteTag(t *testing.T) {
+	prepareGitEnv(t)
+	upstream := upstreamRepo(t, 2)
+	plugin := tools.Plugin{Name: "plugin", URL: upstream}
+	clonePlugin(t, upstream, "plugin")
+
+	git(t, upstream, "tag", "v0.10")
+	git(t, upstream, "tag", "release/v0.9")
+	tag, err := plugin.LatestRemoteTag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "v0.10" {
+		t.Errorf("got %s, want v0.10", tag)
+	}
+}
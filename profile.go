@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Kinds of startup cost.
+const (
+	ProfileConfig = "config"
+	ProfilePlugin = "plugin"
+)
+
+// ProfileEntry is the time spent loading one plugin or config file at
+// startup.
+type ProfileEntry struct {
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"ns"`
+}
+
+// StateDir returns Neovim's state directory, as given by stdpath('state').
+func StateDir() string {
+	stateHome, ok := os.LookupEnv("XDG_STATE_HOME")
+	if !ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			panic("XDG_STATE_HOME or HOME must be set.")
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "nvim")
+}
+
+// ProfilePath ....
+func ProfilePath() string {
+	return filepath.Join(StateDir(), "vim-tools-profile.json")
+}
+
+// profileHeader defines the helper the instrumented config uses to time
+// each step. Errors are passed on so that a broken config still fails the
+// same way it would without the timing.
+const profileHeader = `local vim_tools_profile = {}
+local function vim_tools_timed(kind, name, fn)
+  local hrtime = (vim.uv or vim.loop).hrtime
+  local start = hrtime()
+  local ok, err = pcall(fn)
+  table.insert(vim_tools_profile, { kind = kind, name = name, ns = hrtime() - start })
+  if not ok then
+    error(err, 0)
+  end
+end
+
+`
+
+// profileFooter writes the timings once startup is over.
+const profileFooter = `
+vim.api.nvim_create_autocmd('VimEnter', {
+  once = true,
+  callback = function()
+    local path = vim.fn.stdpath('state') .. '/vim-tools-profile.json'
+    vim.fn.mkdir(vim.fn.fnamemodify(path, ':h'), 'p')
+    local f = io.open(path, 'w')
+    if f then
+      f:write(vim.json.encode(vim_tools_profile))
+      f:close()
+    end
+  end,
+})
+`
+
+// timedLua wraps a line of Lua so that the time it takes is recorded.
+func timedLua(kind, name, lua string) string {
+	return fmt.Sprintf("vim_tools_timed(%s, %s, function() %s end)", luaQuote(kind), luaQuote(name), lua)
+}
+
+// ReadProfile returns the timings written by the instrumented config, most
+// expensive first.
+func ReadProfile() ([]ProfileEntry, error) {
+	data, err := afero.ReadFile(Filesys, ProfilePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	entries := []ProfileEntry{}
+	// an empty Lua table is encoded as an object rather than an array
+	if strings.TrimSpace(string(data)) != "{}" {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal profile json: %w", err)
+		}
+	}
+	SortProfile(entries)
+	return entries, nil
+}
+
+// SortProfile sorts entries most expensive first.
+func SortProfile(entries []ProfileEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Duration > entries[j].Duration
+	})
+}
+
+// sourced lines of --startuptime output look like
+//
+//	012.345  001.234  000.567: sourcing /path/to/file.vim
+//	012.345  001.234  000.567: require('module')
+var startupLine = regexp.MustCompile(`^\s*[\d.]+\s+[\d.]+\s+([\d.]+): (?:sourcing (.+)|require\('([^']+)'\))$`)
+
+// ParseStartupTime reads the output of nvim --startuptime and adds up the
+// time spent in the files of each plugin and config file, most expensive
+// first. Only the time spent in a file itself is counted, so that files
+// sourced by other files are not counted twice.
+func (p Plugins) ParseStartupTime(r io.Reader) ([]ProfileEntry, error) {
+	totals := map[ProfileEntry]time.Duration{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := startupLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		ms, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		path := m[2]
+		if path == "" {
+			path = p.modulePath(m[3])
+		}
+		if key, ok := p.startupOwner(path); ok {
+			totals[key] += time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read startup time: %w", err)
+	}
+	entries := []ProfileEntry{}
+	for key, d := range totals {
+		key.Duration = d
+		entries = append(entries, key)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	SortProfile(entries)
+	return entries, nil
+}
+
+// modulePath finds the file a required Lua module was loaded from.
+func (p Plugins) modulePath(module string) string {
+	rel := strings.ReplaceAll(module, ".", string(filepath.Separator))
+	dirs := []string{filepath.Dir(ConfigFileDir())}
+	for _, name := range p.SortedNames() {
+		dirs = append(dirs, filepath.Join(p[name].Dir(), "lua"))
+	}
+	for _, dir := range dirs {
+		for _, candidate := range []string{rel + ".lua", filepath.Join(rel, "init.lua")} {
+			path := filepath.Join(dir, candidate)
+			if _, err := Filesys.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// startupOwner returns the plugin or config file a file belongs to.
+func (p Plugins) startupOwner(path string) (ProfileEntry, bool) {
+	if path == "" {
+		return ProfileEntry{}, false
+	}
+	if rel, err := filepath.Rel(ConfigFileDir(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		for _, name := range p.SortedNames() {
+			if rel == p[name].ConfigFile || strings.HasPrefix(rel, p[name].ConfigFile+string(filepath.Separator)) {
+				return ProfileEntry{Kind: ProfileConfig, Name: name}, true
+			}
+		}
+		return ProfileEntry{}, false
+	}
+	if rel, err := filepath.Rel(PluginDir(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		name := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+		if _, ok := p[name]; ok {
+			return ProfileEntry{Kind: ProfilePlugin, Name: name}, true
+		}
+	}
+	return ProfileEntry{}, false
+}
@@ -0,0 +1,102 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+var profilePlugins = tools.Plugins{
+	"telescope.nvim": {Name: "telescope.nvim", CleanName: "telescope-nvim", ConfigFile: "telescope-nvim.lua", Enabled: true},
+	"vim-fugitive":   {Name: "vim-fugitive", CleanName: "vim-fugitive", ConfigFile: "vim-fugitive.vim", Enabled: true},
+	"disabled":       {Name: "disabled", CleanName: "disabled", ConfigFile: "disabled.lua", Enabled: false},
+}
+
+func TestParseStartupTime(t *testing.T) {
+	prepareEnv(t)
+
+	module := filepath.Join(tools.PluginDir(), "telescope.nvim", "lua", "telescope", "init.lua")
+	if err := afero.WriteFile(tools.Filesys, module, []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log := strings.Join([]string{
+		"000.008  000.008: --- NVIM STARTING ---",
+		"010.000  005.000  002.000: sourcing " + filepath.Join(tools.PluginDir(), "vim-fugitive", "plugin", "fugitive.vim"),
+		"012.000  001.500  001.500: require('telescope')",
+		"013.000  000.500  000.500: require('plugins.telescope-nvim')",
+		"014.000  000.250  000.250: sourcing " + filepath.Join(tools.ConfigFileDir(), "vim-fugitive.vim"),
+		"015.000  003.000  003.000: sourcing /usr/share/nvim/runtime/plugin/netrwPlugin.vim",
+		"016.000  000.010: --- NVIM STARTED ---",
+	}, "\n")
+	if err := afero.WriteFile(tools.Filesys, filepath.Join(tools.ConfigFileDir(), "telescope-nvim.lua"), []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := profilePlugins.ParseStartupTime(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tools.ProfileEntry{
+		{Kind: tools.ProfilePlugin, Name: "vim-fugitive", Duration: 2 * time.Millisecond},
+		{Kind: tools.ProfilePlugin, Name: "telescope.nvim", Duration: 1500 * time.Microsecond},
+		{Kind: tools.ProfileConfig, Name: "telescope.nvim", Duration: 500 * time.Microsecond},
+		{Kind: tools.ProfileConfig, Name: "vim-fugitive", Duration: 250 * time.Microsecond},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	prepareEnv(t)
+	t.Setenv("XDG_STATE_HOME", "_TEST_")
+
+	t.Run("instrumented config", func(t *testing.T) {
+		if err := (tools.Config{Profile: true}).Write(); err != nil {
+			t.Fatal(err)
+		}
+		if err := profilePlugins.RebuildConfig(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := afero.ReadFile(tools.Filesys, tools.AllPluginsPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"\" packadd! disabled\n",
+			"packadd! telescope.nvim\n",
+			"vim.fn.stdpath('state') .. '/vim-tools-profile.json'",
+		} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%q not in %q", want, data)
+			}
+		}
+		// packadd! does not load anything, so timing it would only mislead
+		if strings.Contains(string(data), "vim_tools_timed('packadd'") {
+			t.Errorf("packadd! is timed in %q", data)
+		}
+	})
+
+	t.Run("read timings", func(t *testing.T) {
+		data := `[{"kind":"plugin","name":"a","ns":1000},{"kind":"config","name":"b","ns":5000}]`
+		if err := afero.WriteFile(tools.Filesys, tools.ProfilePath(), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tools.ReadProfile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []tools.ProfileEntry{
+			{Kind: tools.ProfileConfig, Name: "b", Duration: 5000},
+			{Kind: tools.ProfilePlugin, Name: "a", Duration: 1000},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
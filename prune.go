@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConfigArchiveDir ....
+func ConfigArchiveDir() string {
+	return filepath.Join(MetadataDir(), "config-archive")
+}
+
+// ArchiveConfigs moves the given config files, which are relative to
+// ConfigFileDir, into a new timestamped directory under ConfigArchiveDir,
+// moving them back on rollback. The directory is returned.
+func (tx *Tx) ArchiveConfigs(configs []string, now time.Time) (string, error) {
+	dir, err := newArchiveDir(now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	// undone last, once the configs have been moved back out
+	tx.OnRollback(func() error {
+		return Filesys.Remove(dir)
+	})
+	for _, cf := range configs {
+		if err := tx.Rename(filepath.Join(ConfigFileDir(), cf), filepath.Join(dir, cf)); err != nil {
+			return dir, fmt.Errorf("failed to archive %s: %w", cf, err)
+		}
+	}
+	return dir, nil
+}
+
+// newArchiveDir creates a directory under ConfigArchiveDir named after now,
+// with a suffix should an archive of the same second exist.
+func newArchiveDir(now time.Time) (string, error) {
+	if err := Filesys.MkdirAll(ConfigArchiveDir(), 0o755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(ConfigArchiveDir(), now.Format("20060102-150405"))
+	for i := 0; ; i++ {
+		dir := base
+		if i > 0 {
+			dir = fmt.Sprintf("%s-%d", base, i)
+		}
+		err := Filesys.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+// PluginsWithoutConfig returns the sorted names of the plugins whose config
+// file does not exist.
+func (p Plugins) PluginsWithoutConfig() []string {
+	names := []string{}
+	for _, name := range p.SortedNames() {
+		if _, err := Filesys.Stat(p[name].ConfigFilePath()); err != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReattachConfig moves an orphaned config file, relative to ConfigFileDir,
+// to where the named plugin expects its config to be, moving it back on
+// rollback. The kind of config is kept. The updated plugin is returned.
+func (p Plugins) ReattachConfig(tx *Tx, config, name string) (Plugin, error) {
+	plugin, ok := p[name]
+	if !ok {
+		return Plugin{}, fmt.Errorf("no such plugin %s", name)
+	}
+	plugin = plugin.WithConfigKind(Plugin{ConfigFile: config}.ConfigKind())
+	if plugin.ConfigFile == config {
+		// already in the right place, only the kind was wrong
+		p[name] = plugin
+		return plugin, nil
+	}
+	if _, err := Filesys.Stat(plugin.ConfigFilePath()); err == nil {
+		return Plugin{}, fmt.Errorf("%s: config %s already exists", name, plugin.ConfigFile)
+	}
+	if err := tx.Rename(filepath.Join(ConfigFileDir(), config), plugin.ConfigFilePath()); err != nil {
+		return Plugin{}, fmt.Errorf("%s: failed to move %s: %w", name, config, err)
+	}
+	p[name] = plugin
+	return plugin, nil
+}
+
+// normalizeName reduces a plugin or config name to what distinguishes it.
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".lua", ".vim"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	for _, affix := range []string{"-nvim", ".nvim", "-vim", "-lua"} {
+		name = strings.TrimSuffix(name, affix)
+	}
+	for _, affix := range []string{"nvim-", "vim-"} {
+		name = strings.TrimPrefix(name, affix)
+	}
+	return strings.Map(func(c rune) rune {
+		if c == '-' || c == '_' || c == '.' {
+			return -1
+		}
+		return c
+	}, name)
+}
+
+// levenshtein ....
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FuzzyMatch returns the candidate most like name. Only close matches are
+// considered and a tie between candidates is no match at all.
+func FuzzyMatch(name string, candidates []string) (string, bool) {
+	want := normalizeName(name)
+	best, bestDist, tie := "", -1, false
+	for _, c := range candidates {
+		have := normalizeName(c)
+		dist := levenshtein(want, have)
+		longest := len(want)
+		if len(have) > longest {
+			longest = len(have)
+		}
+		shortest := len(want) + len(have) - longest
+		contains := shortest >= 3 && shortest*2 >= longest &&
+			(strings.Contains(want, have) || strings.Contains(have, want))
+		if dist > longest/4 && !contains {
+			continue
+		}
+		if contains && dist > longest/4 {
+			// containment is a weaker match than a near spelling
+			dist = longest/4 + 1
+		}
+		switch {
+		case bestDist < 0 || dist < bestDist:
+			best, bestDist, tie = c, dist, false
+		case dist == bestDist:
+			tie = true
+		}
+	}
+	if bestDist < 0 || tie {
+		return "", false
+	}
+	return best, true
+}
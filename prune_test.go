@@ -0,0 +1,121 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	candidates := []string{"telescope.nvim", "nvim-treesitter", "vim-fugitive", "lualine.nvim", "lualine-ext.nvim"}
+	tests := []struct {
+		config string
+		want   string
+		ok     bool
+	}{
+		{"telescope.lua", "telescope.nvim", true},
+		{"treesitter.lua", "nvim-treesitter", true},
+		{"nvim-treesiter.lua", "nvim-treesitter", true},
+		{"fugitive.vim", "vim-fugitive", true},
+		{"lualine-nvim.lua", "lualine.nvim", true},
+		{"gitsigns-nvim.lua", "", false},
+		{"lua", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tools.FuzzyMatch(tt.config, candidates)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FuzzyMatch(%q) = %q, %v, want %q, %v", tt.config, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestArchiveConfigs(t *testing.T) {
+	prepareEnv(t)
+	f := tools.Filesys
+	exists := func(path string) bool {
+		_, err := f.Stat(path)
+		return err == nil
+	}
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	for _, cf := range []string{"old.lua", "older.vim"} {
+		afero.WriteFile(f, filepath.Join(tools.ConfigFileDir(), cf), []byte(cf), 0o644)
+	}
+	tx := tools.Begin(tools.Plugins{})
+	first, err := tx.ArchiveConfigs([]string{"old.lua"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a second prune in the same second must not overwrite the first
+	second, err := tx.ArchiveConfigs([]string{"older.vim"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("both archives went to %s", first)
+	}
+	if !exists(filepath.Join(first, "old.lua")) || !exists(filepath.Join(second, "older.vim")) {
+		t.Errorf("configs not archived to %s and %s", first, second)
+	}
+	if exists(filepath.Join(tools.ConfigFileDir(), "old.lua")) {
+		t.Error("old.lua still in the config directory")
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cf := range []string{"old.lua", "older.vim"} {
+		if !exists(filepath.Join(tools.ConfigFileDir(), cf)) {
+			t.Errorf("%s not restored on rollback", cf)
+		}
+	}
+	if exists(first) || exists(second) {
+		t.Error("archive directories left behind on rollback")
+	}
+}
+
+func TestReattachConfig(t *testing.T) {
+	prepareEnv(t)
+	f := tools.Filesys
+	exists := func(path string) bool {
+		_, err := f.Stat(path)
+		return err == nil
+	}
+
+	plugins := tools.Plugins{}
+	plugins.Add("https://github.com/nvim-telescope/telescope.nvim", "", "")
+	plugins.Add("https://github.com/tpope/vim-fugitive", "", "")
+	afero.WriteFile(f, filepath.Join(tools.ConfigFileDir(), "telescope.vim"), []byte("x"), 0o644)
+	afero.WriteFile(f, filepath.Join(tools.ConfigFileDir(), "vim-fugitive.lua"), []byte("x"), 0o644)
+	if got := plugins.PluginsWithoutConfig(); len(got) != 1 || got[0] != "telescope.nvim" {
+		t.Fatalf("PluginsWithoutConfig() = %v, want telescope.nvim", got)
+	}
+
+	tx := tools.Begin(plugins)
+	plugin, err := plugins.ReattachConfig(tx, "telescope.vim", "telescope.nvim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.ConfigFile != "telescope-nvim.vim" || plugins["telescope.nvim"].ConfigFile != plugin.ConfigFile {
+		t.Errorf("got config %s, want telescope-nvim.vim", plugin.ConfigFile)
+	}
+	if !exists(plugin.ConfigFilePath()) || exists(filepath.Join(tools.ConfigFileDir(), "telescope.vim")) {
+		t.Error("config not moved")
+	}
+	if _, err := plugins.ReattachConfig(tx, "telescope.vim", "vim-fugitive"); err == nil {
+		t.Error("expected an error reattaching over an existing config")
+	}
+	if _, err := plugins.ReattachConfig(tx, "telescope.vim", "nope"); err == nil {
+		t.Error("expected an error for an unknown plugin")
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(tools.ConfigFileDir(), "telescope.vim")) || exists(plugin.ConfigFilePath()) {
+		t.Error("config not moved back on rollback")
+	}
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStaleAfter is how long a plugin may go without an upstream commit
+// before it is stale, unless configured otherwise.
+const DefaultStaleAfter = 365 * 24 * time.Hour
+
+// staleRef is where StaleStatus fetches upstream's default branch to.
+const staleRef = "refs/vim-tools/stale"
+
+// StaleStatus is what upstream says about whether a plugin is still alive.
+type StaleStatus struct {
+	LastCommit time.Time // of upstream's default branch
+	Gone       string    // why the remote could not be fetched, if it has vanished
+	Markers    []string  // README lines saying the plugin is archived or deprecated
+}
+
+// IsStale reports whether upstream has had no commit for longer than age.
+func (s StaleStatus) IsStale(now time.Time, age time.Duration) bool {
+	return !s.LastCommit.IsZero() && now.Sub(s.LastCommit) > age
+}
+
+var ageUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+var ageFormat = regexp.MustCompile(`^(\d+)([dwy])$`)
+
+// ParseAge parses an age such as "90d", "26w" or "2y", or any duration
+// time.ParseDuration accepts. An empty age is DefaultStaleAfter.
+func ParseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return DefaultStaleAfter, nil
+	}
+	if m := ageFormat.FindStringSubmatch(age); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err == nil && n > 0 {
+			return time.Duration(n) * ageUnits[m[2]], nil
+		}
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q, use e.g. 90d, 26w or 2y", age)
+	}
+	return d, nil
+}
+
+// StaleAfter returns the configured age after which a plugin is stale.
+func StaleAfter() (time.Duration, error) {
+	config, err := ReadConfig()
+	if err != nil {
+		return 0, err
+	}
+	return ParseAge(config.StaleAfter)
+}
+
+// FormatAge formats an age in the largest whole unit ParseAge knows.
+func FormatAge(d time.Duration) string {
+	for _, unit := range []string{"y", "w", "d"} {
+		if n := d / ageUnits[unit]; n > 0 {
+			return fmt.Sprintf("%d%s", n, unit)
+		}
+	}
+	return "0d"
+}
+
+// goneMarkers are what git says when a remote no longer exists. Hosts
+// that hide private repos ask for credentials instead.
+var goneMarkers = []string{
+	"not found",
+	"does not exist",
+	"does not appear to be a git repository",
+	"could not read username",
+	"terminal prompts disabled",
+	"authentication failed",
+}
+
+// remoteGone returns the line of git's output on failing to fetch that
+// says the remote has vanished, or "" if it could just not be reached.
+func remoteGone(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		lower := strings.ToLower(line)
+		for _, marker := range goneMarkers {
+			if strings.Contains(lower, marker) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+var archiveMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(this|the)\s+(repo|repository|project|plugin)\s+(is|has\s+been)\s+(now\s+)?(archived|deprecated|unmaintained|abandoned|discontinued)`),
+	regexp.MustCompile(`(?i)\b(this|the)\s+(repo|repository|project|plugin)\s+(has\s+)?(been\s+)?(moved|migrated)\s+to\b`),
+	regexp.MustCompile(`(?i)\bno\s+longer\s+(being\s+)?(actively\s+)?(maintained|developed|supported)`),
+	regexp.MustCompile(`(?i)\b(is\s+)?(unmaintained|not\s+(actively\s+)?maintained)\b`),
+	regexp.MustCompile(`\b(DEPRECATED|ARCHIVED|UNMAINTAINED)\b`),
+}
+
+// ArchiveMarkers returns the lines of a README that say the plugin is
+// archived, deprecated or unmaintained.
+func ArchiveMarkers(readme string) []string {
+	found := []string{}
+	for _, line := range strings.Split(readme, "\n") {
+		for _, re := range archiveMarkers {
+			if re.MatchString(line) {
+				line = strings.TrimSpace(line)
+				if len(line) > 100 {
+					line = line[:97] + "..."
+				}
+				found = append(found, line)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// StaleStatus fetches upstream's default branch and reports when it last
+// had a commit and whether its README says it is archived. A remote that
+// has vanished is reported rather than being an error.
+func (plugin *Plugin) StaleStatus() (StaleStatus, error) {
+	status := StaleStatus{}
+	if _, err := os.Stat(plugin.Dir()); err != nil {
+		return status, fmt.Errorf("%s: not cloned, run vim-check", plugin.Name)
+	}
+	// a repository that is gone may ask for credentials rather than fail.
+	// FETCH_HEAD is left alone since vim-check and vim-outdated rely on it.
+	out, err := plugin.runGitEnv(plugin.Dir(), 30*time.Second, []string{"GIT_TERMINAL_PROMPT=0"},
+		"fetch", "--quiet", "--no-tags", "--no-write-fetch-head", plugin.URL, "+HEAD:"+staleRef)
+	if err != nil {
+		if gone := remoteGone(out); gone != "" {
+			status.Gone = gone
+			return status, nil
+		}
+		return status, fmt.Errorf("failed to fetch %s: %w", plugin.URL, err)
+	}
+	date, err := plugin.RunGit("log", "-1", "--format=%cI", staleRef)
+	if err != nil {
+		return status, err
+	}
+	if status.LastCommit, err = time.Parse(time.RFC3339, date); err != nil {
+		return status, fmt.Errorf("%s: unexpected log output %q: %w", plugin.Name, date, err)
+	}
+	readme, err := plugin.upstreamReadme()
+	if err != nil {
+		return status, err
+	}
+	status.Markers = ArchiveMarkers(readme)
+	// the fetched commits need not be kept once they have been looked at
+	if _, err := plugin.RunGit("update-ref", "-d", staleRef); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// upstreamReadme returns the README of the fetched default branch, since
+// a notice of archival is not in the README of an older checkout.
+func (plugin *Plugin) upstreamReadme() (string, error) {
+	files, err := plugin.RunGit("ls-tree", "--name-only", staleRef)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range strings.Split(files, "\n") {
+		if strings.HasPrefix(strings.ToLower(name), "readme") {
+			return plugin.RunGit("show", staleRef+":"+name)
+		}
+	}
+	return "", nil
+}
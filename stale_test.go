@@ -0,0 +1,111 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+)
+
+func TestStale(t *testing.T) {
+	t.Run("age", func(t *testing.T) {
+		day := 24 * time.Hour
+		for age, want := range map[string]time.Duration{
+			"":     365 * day,
+			"90d":  90 * day,
+			"26w":  26 * 7 * day,
+			"2y":   2 * 365 * day,
+			"720h": 30 * day,
+		} {
+			got, err := tools.ParseAge(age)
+			if err != nil || got != want {
+				t.Errorf("ParseAge(%q) = %v, %v, want %v", age, got, err, want)
+			}
+		}
+		for _, age := range []string{"0d", "-1y", "1 year", "y"} {
+			if _, err := tools.ParseAge(age); err == nil {
+				t.Errorf("ParseAge(%q) should fail", age)
+			}
+		}
+		for d, want := range map[time.Duration]string{
+			3 * time.Hour:  "0d",
+			10 * day:       "1w",
+			400 * day:      "1y",
+			2*365*day + 40: "2y",
+		} {
+			if got := tools.FormatAge(d); got != want {
+				t.Errorf("FormatAge(%v) = %q, want %q", d, got, want)
+			}
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		year := 365 * 24 * time.Hour
+		if (tools.StaleStatus{LastCommit: now.AddDate(-2, 0, 0)}).IsStale(now, year) != true {
+			t.Error("a plugin without commits for two years should be stale")
+		}
+		if (tools.StaleStatus{LastCommit: now.AddDate(0, -6, 0)}).IsStale(now, year) != false {
+			t.Error("a plugin with a commit six months ago should not be stale")
+		}
+	})
+
+	t.Run("markers", func(t *testing.T) {
+		readme := `# plugin.nvim
+
+> [!WARNING]
+> This plugin is no longer maintained, use other.nvim instead.
+
+Options marked as deprecated will be removed.
+
+## DEPRECATED
+
+This repository has been archived by the owner.
+The project has moved to https://codeberg.org/user/plugin.nvim
+`
+		want := []string{
+			"> This plugin is no longer maintained, use other.nvim instead.",
+			"## DEPRECATED",
+			"This repository has been archived by the owner.",
+			"The project has moved to https://codeberg.org/user/plugin.nvim",
+		}
+		if got := tools.ArchiveMarkers(readme); !reflect.DeepEqual(got, want) {
+			t.Errorf("ArchiveMarkers() = %q, want %q", got, want)
+		}
+		if got := tools.ArchiveMarkers("# plugin\n\nA maintained plugin.\n"); len(got) != 0 {
+			t.Errorf("ArchiveMarkers() = %q, want none", got)
+		}
+	})
+}
+
+func TestStaleStatus(t *testing.T) {
+	prepareGitEnv(t)
+	upstream := upstreamRepo(t, 1)
+	plugin := clonePlugin(t, upstream, "plugin")
+	commitFile(t, upstream, "README.md", "This plugin is no longer maintained.\n")
+
+	status, err := plugin.StaleStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Gone != "" || time.Since(status.LastCommit) > time.Hour {
+		t.Errorf("got %+v, want a recent commit", status)
+	}
+	if want := []string{"This plugin is no longer maintained."}; !reflect.DeepEqual(status.Markers, want) {
+		t.Errorf("got markers %q, want %q", status.Markers, want)
+	}
+	if _, err := os.Stat(filepath.Join(plugin.Dir(), ".git", "FETCH_HEAD")); !os.IsNotExist(err) {
+		t.Errorf("FETCH_HEAD was written: %v", err)
+	}
+	if refs := git(t, plugin.Dir(), "for-each-ref", "refs/vim-tools"); refs != "" {
+		t.Errorf("fetched refs left behind: %s", refs)
+	}
+
+	plugin.URL += "-gone"
+	if status, err = plugin.StaleStatus(); err != nil || status.Gone == "" {
+		t.Errorf("got %+v, %v, want the remote gone", status, err)
+	}
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/afero"
+)
+
+// DefaultConfigTemplate is used when no template has been configured.
+const DefaultConfigTemplate = `{{if .Module}}require('{{.Module}}').setup({})
+{{end}}`
+
+// ConfigTemplateData is what a config file template is executed with.
+type ConfigTemplateData struct {
+	Name      string
+	CleanName string
+	URL       string
+	Module    string
+}
+
+// TemplateDir ....
+func TemplateDir() string {
+	return filepath.Join(ToolConfigDir(), "templates")
+}
+
+// LuaModule returns the name of the Lua module a plugin provides, or "" if
+// it has none. When a plugin provides several, the one most like the
+// plugin's name is chosen.
+func (plugin Plugin) LuaModule() string {
+	ent, err := afero.ReadDir(Filesys, filepath.Join(plugin.Dir(), "lua"))
+	if err != nil {
+		return ""
+	}
+	modules := []string{}
+	for _, e := range ent {
+		switch {
+		case e.IsDir():
+			modules = append(modules, e.Name())
+		case strings.HasSuffix(e.Name(), ".lua"):
+			modules = append(modules, strings.TrimSuffix(e.Name(), ".lua"))
+		}
+	}
+	if len(modules) == 0 {
+		return ""
+	}
+	sort.Strings(modules)
+	want := strings.ToLower(plugin.Name)
+	for _, affix := range []string{".nvim", "-nvim", ".lua", ".vim"} {
+		want = strings.TrimSuffix(want, affix)
+	}
+	for _, affix := range []string{"nvim-", "vim-"} {
+		want = strings.TrimPrefix(want, affix)
+	}
+	for _, m := range modules {
+		if strings.ToLower(m) == want {
+			return m
+		}
+	}
+	return modules[0]
+}
+
+// ConfigTemplate returns the template for a plugin's config file. A
+// template named after the plugin in TemplateDir is preferred, then the
+// default template there, then DefaultConfigTemplate. Vimscript config
+// files use templates ending in .vim.tmpl instead and have no builtin
+// template.
+func (plugin Plugin) ConfigTemplate() (*template.Template, error) {
+	suffix, builtin := ".tmpl", DefaultConfigTemplate
+	if plugin.ConfigKind() == ConfigVim {
+		suffix, builtin = ".vim.tmpl", ""
+	}
+	for _, name := range []string{plugin.Name + suffix, "default" + suffix} {
+		text, err := afero.ReadFile(Filesys, filepath.Join(TemplateDir(), name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		return template.New(name).Parse(string(text))
+	}
+	return template.New("builtin").Parse(builtin)
+}
+
+// RenderConfig returns the initial contents of a plugin's config file.
+func (plugin Plugin) RenderConfig() (string, error) {
+	tmpl, err := plugin.ConfigTemplate()
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to parse config template: %w", plugin.Name, err)
+	}
+	data := ConfigTemplateData{
+		Name:      plugin.Name,
+		CleanName: plugin.CleanName,
+		URL:       plugin.URL,
+		Module:    plugin.LuaModule(),
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: failed to execute config template: %w", plugin.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// ReadmeSetupSnippet returns the first Lua code block in a plugin's README
+// that calls a setup function, or "" if there is none.
+func (plugin Plugin) ReadmeSetupSnippet() (string, error) {
+	ent, err := afero.ReadDir(Filesys, plugin.Dir())
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to read plugin directory: %w", plugin.Name, err)
+	}
+	for _, e := range ent {
+		if e.IsDir() || !strings.HasPrefix(strings.ToLower(e.Name()), "readme") {
+			continue
+		}
+		data, err := afero.ReadFile(Filesys, filepath.Join(plugin.Dir(), e.Name()))
+		if err != nil {
+			return "", fmt.Errorf("%s: failed to read README: %w", plugin.Name, err)
+		}
+		if snippet := setupSnippet(string(data)); snippet != "" {
+			return snippet, nil
+		}
+	}
+	return "", nil
+}
+
+// setupSnippet finds the first fenced Lua code block calling setup.
+func setupSnippet(markdown string) string {
+	var block strings.Builder
+	inLua := false
+	scanner := bufio.NewScanner(strings.NewReader(markdown))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fence := strings.TrimSpace(line)
+		switch {
+		case !inLua && strings.HasPrefix(fence, "```") && strings.TrimSpace(strings.TrimPrefix(fence, "```")) == "lua":
+			inLua = true
+			block.Reset()
+		case inLua && strings.HasPrefix(fence, "```"):
+			inLua = false
+			if strings.Contains(block.String(), "setup(") || strings.Contains(block.String(), "setup {") {
+				return block.String()
+			}
+		case inLua:
+			block.WriteString(line + "\n")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestRenderConfig(t *testing.T) {
+	prepareEnv(t)
+
+	f := tools.Filesys
+	createInstalledPlugin(t, f, "telescope.nvim", "telescope-nvim", false)
+	f.MkdirAll(filepath.Join(tools.PluginDir(), "telescope.nvim", "lua", "plenary_compat"), 0o755)
+	f.MkdirAll(filepath.Join(tools.PluginDir(), "telescope.nvim", "lua", "telescope"), 0o755)
+	createInstalledPlugin(t, f, "vim-fugitive", "vim-fugitive", false)
+
+	plugins := tools.Plugins{}
+	telescope := plugins.Add("https://github.com/nvim-telescope/telescope.nvim", "", "")
+	fugitive := plugins.Add("https://github.com/tpope/vim-fugitive", "", "")
+
+	t.Run("builtin template", func(t *testing.T) {
+		got, err := telescope.RenderConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "require('telescope').setup({})\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		got, err = fugitive.RenderConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "" {
+			t.Errorf("got %q, want an empty config for a plugin without a Lua module", got)
+		}
+	})
+
+	f.MkdirAll(tools.TemplateDir(), 0o755)
+	afero.WriteFile(f, filepath.Join(tools.TemplateDir(), "default.tmpl"), []byte("-- {{.Name}} ({{.URL}})\n"), 0o644)
+	afero.WriteFile(f, filepath.Join(tools.TemplateDir(), "telescope.nvim.tmpl"), []byte("local {{.Module}} = require('{{.Module}}') -- {{.CleanName}}\n"), 0o644)
+	t.Run("configured templates", func(t *testing.T) {
+		got, err := telescope.RenderConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "local telescope = require('telescope') -- telescope-nvim\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		got, err = fugitive.RenderConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "-- vim-fugitive (https://github.com/tpope/vim-fugitive)\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestReadmeSetupSnippet(t *testing.T) {
+	prepareEnv(t)
+
+	f := tools.Filesys
+	createInstalledPlugin(t, f, "gitsigns.nvim", "gitsigns-nvim", false)
+	readme := "# gitsigns\n\n```lua\nvim.o.signcolumn = 'yes'\n```\n\n## Setup\n\n```lua\nrequire('gitsigns').setup {\n  signs = {},\n}\n```\n"
+	afero.WriteFile(f, filepath.Join(tools.PluginDir(), "gitsigns.nvim", "README.md"), []byte(readme), 0o644)
+
+	plugin := tools.Plugins{}.Add("https://github.com/lewis6991/gitsigns.nvim", "", "")
+	got, err := plugin.ReadmeSetupSnippet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "require('gitsigns').setup {\n  signs = {},\n}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -21,13 +22,21 @@ var Filesys = afero.NewOsFs()
 
 // Plugin ....
 type Plugin struct {
-	Name        string `json:"name"`
-	URL         string `json:"url"` // not always a url
-	CleanName   string `json:"clean_name"`
-	ConfigFile  string `json:"config_file"`
-	Colorscheme bool   `json:"colorscheme"`
-	Enabled     bool   `json:"enabled"`
-	Version     string `json:"version"`
+	Name        string        `json:"name"`
+	URL         string        `json:"url"` // not always a url
+	CleanName   string        `json:"clean_name"`
+	ConfigFile  string        `json:"config_file"`
+	Colorscheme bool          `json:"colorscheme"`
+	Enabled     bool          `json:"enabled"`
+	Version     string        `json:"version"`
+	Clone       *CloneOptions `json:"clone,omitempty"`   // overrides the global clone options
+	Depends     []string      `json:"depends,omitempty"` // plugins that must be enabled along with this one
+	Description string        `json:"description,omitempty"`
+	Homepage    string        `json:"homepage,omitempty"`
+	Notes       string        `json:"notes,omitempty"`
+	Added       string        `json:"added,omitempty"`   // date the plugin was added, see AddedFormat
+	Updated     string        `json:"updated,omitempty"` // last time its checkout changed, RFC 3339
+	Commit      string        `json:"commit,omitempty"`  // last commit checked out
 }
 
 // Plugins ....
@@ -96,16 +105,22 @@ func PluginsOnDisk() map[string]string {
 // RebuildConfig ....
 func (p Plugins) RebuildConfig() error {
 	names := p.SortedNames()
+	colorschemes, err := ReadColorschemeSettings()
+	if err != nil {
+		return err
+	}
+	config, err := ReadConfig()
+	if err != nil {
+		return err
+	}
 
-	allPluginsPath := AllPluginsPath()
-	allLuaPlugins, _ := afero.TempFile(
-		Filesys,
-		filepath.Dir(allPluginsPath),
-		filepath.Base(allPluginsPath),
-	)
-	defer allLuaPlugins.Close()
-	defer Filesys.Remove(allLuaPlugins.Name())
+	allLuaPlugins := &bytes.Buffer{}
 
+	// packadd! only adds to 'runtimepath', so with profiling on only the
+	// config files are timed; vim-profile -run times loading the plugins
+	if config.Profile {
+		fmt.Fprint(allLuaPlugins, profileHeader)
+	}
 	// fmt.Fprint(allLuaPlugins, "vim.pack.add({\n")
 	fmt.Fprint(allLuaPlugins, "vim.cmd[[\n")
 	for _, name := range names {
@@ -122,14 +137,12 @@ func (p Plugins) RebuildConfig() error {
 	fmt.Fprint(allLuaPlugins, "-- colorscheme\n")
 	// fmt.Fprint(allLuaPlugins, "})\n\n")
 	// fmt.Fprint(allLuaPlugins, "-- colorscheme\n")
-	for _, name := range names {
-		plugin := p[name]
-		if plugin.Colorscheme && plugin.IsEnabled() {
-			if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
-				fmt.Fprintf(allLuaPlugins, "require'plugins.%s'\n\n", plugin.CleanName)
-				break // only allow the first enabled colorscheme
-			}
-		}
+	if p.backgroundAware(colorschemes) {
+		p.writeBackgroundColorschemes(allLuaPlugins, colorschemes)
+	} else if p.usable(colorschemes.Active) {
+		p.writeColorscheme(allLuaPlugins, colorschemes.Active)
+	} else if plugin, ok := p.fallbackColorscheme(); ok {
+		fmt.Fprintf(allLuaPlugins, "%s\n\n", plugin.ConfigLoader())
 	}
 
 	fmt.Fprint(allLuaPlugins, "-- config files\n")
@@ -137,14 +150,22 @@ func (p Plugins) RebuildConfig() error {
 		plugin := p[name]
 		if _, err := os.Stat(plugin.ConfigFilePath()); err == nil {
 			if plugin.IsDisabled() || plugin.Colorscheme {
-				fmt.Fprintf(allLuaPlugins, "-- require'plugins.%s'\n", plugin.CleanName)
+				fmt.Fprintf(allLuaPlugins, "-- %s\n", plugin.ConfigLoader())
+			} else if config.Profile {
+				fmt.Fprintf(allLuaPlugins, "%s\n", timedLua(ProfileConfig, plugin.Name, plugin.ConfigLoader()))
 			} else {
-				fmt.Fprintf(allLuaPlugins, "require'plugins.%s'\n", plugin.CleanName)
+				fmt.Fprintf(allLuaPlugins, "%s\n", plugin.ConfigLoader())
 			}
 		}
 	}
+	if config.Profile {
+		fmt.Fprint(allLuaPlugins, profileFooter)
+	}
 
-	return Filesys.Rename(allLuaPlugins.Name(), allPluginsPath)
+	if err := writeFile(AllPluginsPath(), allLuaPlugins.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", AllPluginsPath(), err)
+	}
+	return nil
 }
 
 func ConfigsOnDisk() map[string]bool {
@@ -153,11 +174,10 @@ func ConfigsOnDisk() map[string]bool {
 		fmt.Fprintf(os.Stderr, "Cannot read plugin directory: %s\n", err)
 		os.Exit(1)
 	}
+	// a config may be a single file or a directory of modules
 	configsOnDisk := make(map[string]bool)
 	for _, f := range ent {
-		if !f.IsDir() {
-			configsOnDisk[f.Name()] = true
-		}
+		configsOnDisk[f.Name()] = true
 	}
 	return configsOnDisk
 }
@@ -190,7 +210,7 @@ func (p Plugins) SortedNames() []string {
 // Add ....
 func (p Plugins) Add(url, name, version string) Plugin {
 	if name == "" {
-		name = filepath.Base(strings.Split(url, ":")[1])
+		name = nameFromURL(url)
 	}
 	plugin := Plugin{
 		Name:        name,
@@ -199,13 +219,15 @@ func (p Plugins) Add(url, name, version string) Plugin {
 		Colorscheme: false,
 		Version:     version,
 	}
-	plugin.CleanName = strings.Map(func(c rune) rune {
-		if c == '.' {
-			return '-'
+	plugin.CleanName = cleanName(name)
+	plugin.ConfigFile = plugin.WithConfigKind(ConfigLua).ConfigFile
+	for _, kind := range []string{ConfigLua, ConfigVim, ConfigDir} {
+		existing := plugin.WithConfigKind(kind)
+		if _, err := Filesys.Stat(existing.ConfigFilePath()); err == nil {
+			plugin = existing
+			break
 		}
-		return c
-	}, name)
-	plugin.ConfigFile = fmt.Sprintf("%s.lua", plugin.CleanName)
+	}
 
 	_, err := Filesys.Stat(filepath.Join(PluginDir(), name, "colors"))
 	if !errors.Is(err, fs.ErrNotExist) {
@@ -215,6 +237,11 @@ func (p Plugins) Add(url, name, version string) Plugin {
 	return p[name]
 }
 
+func nameFromURL(url string) string {
+	parts := strings.Split(url, ":")
+	return filepath.Base(parts[len(parts)-1])
+}
+
 // Remove ....
 func (p Plugins) Remove(plugin Plugin) {
 	delete(p, plugin.Name)
@@ -243,49 +270,159 @@ func (p Plugins) Write() error {
 	if err != nil {
 		return fmt.Errorf("conversion to JSON failed: %w", err)
 	}
-	pf, err := afero.TempFile(Filesys, MetadataDir(), filepath.Base(PluginsFilePath()))
-	defer pf.Close()
+	n, err := backups()
 	if err != nil {
-		return fmt.Errorf("failed to create temp file for plugins file: %w", err)
+		return err
+	}
+	if err := backupPluginsFile(pjson, n); err != nil {
+		return err
 	}
-	fmt.Fprintf(pf, "%s", pjson)
-	if err := Filesys.Rename(pf.Name(), PluginsFilePath()); err != nil {
-		return fmt.Errorf("rename of plugins file failed: %w", err)
+	if err := writeFile(PluginsFilePath(), pjson); err != nil {
+		return fmt.Errorf("failed to write plugins file: %w", err)
 	}
 	return nil
 }
 
 func (plugin *Plugin) CloneRepo() (string, error) {
-	return plugin.runGitFromDir(PluginDir(), "clone", plugin.URL)
+	args, err := plugin.cloneArgs()
+	if err != nil {
+		return "", err
+	}
+	return plugin.runGitFromDir(PluginDir(), args...)
 }
 
 func (plugin *Plugin) RunGit(args ...string) (string, error) {
-	return plugin.runGitFromDir(filepath.Join(PluginDir(), plugin.Name), args...)
+	return plugin.runGitFromDir(plugin.Dir(), args...)
 }
 
 func (plugin *Plugin) runGitFromDir(dir string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	return plugin.runGitTimeout(dir, 30*time.Second, args...)
+}
+
+func (plugin *Plugin) runGitTimeout(dir string, timeout time.Duration, args ...string) (string, error) {
+	return plugin.runGitEnv(dir, timeout, nil, args...)
+}
+
+// runGitEnv runs git with env added to the environment. The output is
+// returned even if git fails so callers can tell why.
+func (plugin *Plugin) runGitEnv(dir string, timeout time.Duration, env []string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("%s: failed to run git: %w", plugin.Name, err)
+		return strings.TrimRight(string(out), "\n"), fmt.Errorf("%s: failed to run git: %w", plugin.Name, err)
 	}
 	return strings.TrimRight(string(out), "\n"), nil
 }
 
+// Dir ....
+func (plugin Plugin) Dir() string {
+	return filepath.Join(PluginDir(), plugin.Name)
+}
+
 // ConfigFilePath ....
 func (plugin Plugin) ConfigFilePath() string {
 	return filepath.Join(ConfigFileDir(), plugin.ConfigFile)
 }
 
+// Config file kinds.
+const (
+	ConfigLua = "lua"
+	ConfigVim = "vim"
+	ConfigDir = "dir"
+)
+
+// ConfigKind ....
+func (plugin Plugin) ConfigKind() string {
+	switch filepath.Ext(plugin.ConfigFile) {
+	case ".lua":
+		return ConfigLua
+	case ".vim":
+		return ConfigVim
+	default:
+		return ConfigDir
+	}
+}
+
+// cleanName turns a plugin name into one usable as a Lua module name.
+func cleanName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c == '.' {
+			return '-'
+		}
+		return c
+	}, name)
+}
+
+// Rename renames a plugin, keeping its settings and config kind, and
+// updates the plugins that depend on it.
+func (p Plugins) Rename(name, newName string) Plugin {
+	plugin := p[name]
+	plugin.Name = newName
+	plugin.CleanName = cleanName(newName)
+	plugin = plugin.WithConfigKind(p[name].ConfigKind())
+	delete(p, name)
+	p[newName] = plugin
+	for other, dependent := range p {
+		for i, dep := range dependent.Depends {
+			if dep == name {
+				depends := append([]string{}, dependent.Depends...)
+				depends[i] = newName
+				dependent.Depends = depends
+				p[other] = dependent
+			}
+		}
+	}
+	return plugin
+}
+
+// WithConfigKind ....
+func (plugin Plugin) WithConfigKind(kind string) Plugin {
+	switch kind {
+	case ConfigVim:
+		plugin.ConfigFile = fmt.Sprintf("%s.vim", plugin.CleanName)
+	case ConfigDir:
+		plugin.ConfigFile = plugin.CleanName
+	default:
+		plugin.ConfigFile = fmt.Sprintf("%s.lua", plugin.CleanName)
+	}
+	return plugin
+}
+
+// ConfigEditPath is the file to edit for a plugin's config. For a directory
+// that is its init.lua.
+func (plugin Plugin) ConfigEditPath() string {
+	if plugin.ConfigKind() == ConfigDir {
+		return filepath.Join(plugin.ConfigFilePath(), "init.lua")
+	}
+	return plugin.ConfigFilePath()
+}
+
+// ConfigLoader returns the Lua statement that loads a plugin's config.
+func (plugin Plugin) ConfigLoader() string {
+	if plugin.ConfigKind() == ConfigVim {
+		return fmt.Sprintf("vim.cmd.source(vim.fn.stdpath('config') .. '/lua/plugins/%s')", plugin.ConfigFile)
+	}
+	return fmt.Sprintf("require'plugins.%s'", plugin.CleanName)
+}
+
 // Freeze ....
 func (plugin Plugin) Freeze(version string) Plugin {
 	plugin.Version = version
 	return plugin
 }
 
+// SetURL ....
+func (plugin Plugin) SetURL(url string) Plugin {
+	plugin.URL = url
+	return plugin
+}
+
 // Thaw
 func (plugin Plugin) Thaw() Plugin {
 	plugin.Version = ""
@@ -114,7 +114,7 @@ func TestAdd(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		if got := plugins.Add(tt.url); !reflect.DeepEqual(got, tt.want) {
+		if got := plugins.Add(tt.url, "", ""); !reflect.DeepEqual(got, tt.want) {
 			t.Errorf("Plugins.Add() = %v, want %v", got, tt.want)
 		}
 	}
@@ -179,7 +179,7 @@ func TestWrite(t *testing.T) {
     "url": "https://gitlab.com/user/colorscheme.nvim",
     "colorscheme": true,
     "enabled": true,
-	"frozen": false,
+	"version": "",
 	"config_file":  "colorscheme-nvim.lua",
 	"clean_name":   "colorscheme-nvim"
   },
@@ -188,7 +188,7 @@ func TestWrite(t *testing.T) {
     "url": "git@github.com:SomeUser/plugin-a",
     "colorscheme": false,
     "enabled": true,
-	"frozen": false,
+	"version": "",
 	"config_file":  "plugin-a.lua",
 	"clean_name":   "plugin-a"
   },
@@ -197,7 +197,7 @@ func TestWrite(t *testing.T) {
     "url": "https://github.com/user/plugin1.nvim",
     "colorscheme": false,
     "enabled": true,
-	"frozen": false,
+	"version": "",
 	"config_file":  "plugin1-nvim.lua",
 	"clean_name":   "plugin1-nvim"
   },
@@ -206,7 +206,7 @@ func TestWrite(t *testing.T) {
     "url": "git@github.com:SomeOtherUser/someotherplugin.nvim",
     "colorscheme": false,
     "enabled": false,
-	"frozen": false,
+	"version": "",
 	"config_file":  "someotherplugin-nvim.lua",
 	"clean_name":   "someotherplugin-nvim"
   }
@@ -331,9 +331,136 @@ func TestRebuildConfig(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		want := "-- load plugins\nvim.cmd[[\npackadd! colorscheme.nvim\npackadd! plugin-a\npackadd! plugin1.nvim\n\" packadd! someotherplugin.nvim\n]]\n\n-- colorscheme\n-- config files\n"
+		want := "vim.cmd[[\npackadd! colorscheme.nvim\npackadd! plugin-a\npackadd! plugin1.nvim\n\" packadd! someotherplugin.nvim\n]]\n\n-- colorscheme\n-- config files\n"
 		if !reflect.DeepEqual(string(data), want) {
 			t.Errorf("got %#v, want %#v", string(data), want)
 		}
 	})
 }
+
+func TestCloneOptions(t *testing.T) {
+	prepareEnv(t)
+
+	plugin := tools.Plugin{Name: "plugin1.nvim"}
+	t.Run("no config file", func(t *testing.T) {
+		got, err := plugin.CloneOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tools.CloneOptions{}) {
+			t.Errorf("got %#v, want full clone", got)
+		}
+	})
+
+	f := tools.Filesys
+	f.MkdirAll(tools.ToolConfigDir(), 0o755)
+	afero.WriteFile(f, tools.ToolConfigPath(), []byte(`{"clone": {"depth": 1}}`), 0o644)
+	t.Run("global config", func(t *testing.T) {
+		got, err := plugin.CloneOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tools.CloneOptions{Depth: 1}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+
+	plugin.Clone = &tools.CloneOptions{Filter: "blob:none"}
+	t.Run("plugin overrides global config", func(t *testing.T) {
+		got, err := plugin.CloneOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tools.CloneOptions{Filter: "blob:none"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	})
+}
+
+func TestConfigKinds(t *testing.T) {
+	prepareEnv(t)
+
+	f := tools.Filesys
+	f.MkdirAll(filepath.Join(tools.PluginDir(), "vim-fugitive"), 0o755)
+	f.Create(filepath.Join(tools.ConfigFileDir(), "vim-fugitive.vim"))
+	f.MkdirAll(filepath.Join(tools.PluginDir(), "telescope.nvim"), 0o755)
+	f.MkdirAll(filepath.Join(tools.ConfigFileDir(), "telescope-nvim"), 0o755)
+	f.MkdirAll(filepath.Join(tools.PluginDir(), "plugin1.nvim"), 0o755)
+
+	plugins := tools.Plugins{}
+	tests := []struct {
+		url        string
+		kind       string
+		configFile string
+		editPath   string
+		loader     string
+	}{
+		{
+			"https://github.com/tpope/vim-fugitive",
+			tools.ConfigVim,
+			"vim-fugitive.vim",
+			filepath.Join(tools.ConfigFileDir(), "vim-fugitive.vim"),
+			"vim.cmd.source(vim.fn.stdpath('config') .. '/lua/plugins/vim-fugitive.vim')",
+		},
+		{
+			"https://github.com/nvim-telescope/telescope.nvim",
+			tools.ConfigDir,
+			"telescope-nvim",
+			filepath.Join(tools.ConfigFileDir(), "telescope-nvim", "init.lua"),
+			"require'plugins.telescope-nvim'",
+		},
+		{
+			"https://github.com/user/plugin1.nvim",
+			tools.ConfigLua,
+			"plugin1-nvim.lua",
+			filepath.Join(tools.ConfigFileDir(), "plugin1-nvim.lua"),
+			"require'plugins.plugin1-nvim'",
+		},
+	}
+	for _, tt := range tests {
+		plugin := plugins.Add(tt.url, "", "")
+		if plugin.ConfigFile != tt.configFile {
+			t.Errorf("%s: got config file %q, want %q", tt.url, plugin.ConfigFile, tt.configFile)
+		}
+		if plugin.ConfigKind() != tt.kind {
+			t.Errorf("%s: got kind %q, want %q", tt.url, plugin.ConfigKind(), tt.kind)
+		}
+		if plugin.ConfigEditPath() != tt.editPath {
+			t.Errorf("%s: got edit path %q, want %q", tt.url, plugin.ConfigEditPath(), tt.editPath)
+		}
+		if plugin.ConfigLoader() != tt.loader {
+			t.Errorf("%s: got loader %q, want %q", tt.url, plugin.ConfigLoader(), tt.loader)
+		}
+	}
+
+	if got := plugins["plugin1.nvim"].WithConfigKind(tools.ConfigVim).ConfigFile; got != "plugin1-nvim.vim" {
+		t.Errorf("got %q, want plugin1-nvim.vim", got)
+	}
+}
+
+func TestRename(t *testing.T) {
+	plugins := tools.Plugins{
+		"old.nvim": {
+			Name: "old.nvim", URL: "https://github.com/user/old.nvim", CleanName: "old-nvim", ConfigFile: "old-nvim.vim",
+			Enabled: false, Colorscheme: true, Version: "v1.0", Depends: []string{"dep"},
+			Description: "a plugin", Notes: "keep", Added: "2024-01-01", Commit: "abc",
+		},
+		"user": {Name: "user", Enabled: true, Depends: []string{"other", "old.nvim"}},
+	}
+	renamed := plugins.Rename("old.nvim", "new.nvim")
+
+	want := plugins["new.nvim"]
+	want.Name, want.CleanName, want.ConfigFile = "old.nvim", "old-nvim", "old-nvim.vim"
+	before := tools.Plugin{
+		Name: "old.nvim", URL: "https://github.com/user/old.nvim", CleanName: "old-nvim", ConfigFile: "old-nvim.vim",
+		Enabled: false, Colorscheme: true, Version: "v1.0", Depends: []string{"dep"},
+		Description: "a plugin", Notes: "keep", Added: "2024-01-01", Commit: "abc",
+	}
+	require.Equal(t, before, want, "only the name and config file should change")
+	require.Equal(t, "new-nvim", renamed.CleanName)
+	require.Equal(t, "new-nvim.vim", renamed.ConfigFile)
+	require.NotContains(t, plugins, "old.nvim")
+	require.Equal(t, []string{"other", "new.nvim"}, plugins["user"].Depends)
+}
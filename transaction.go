@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Tx records the steps of an operation that changes the plugins file, the
+// pack directory and the generated config together. Should any step fail
+// the steps before it are undone, so that the three stay consistent. A
+// committed transaction is recorded in the journal along with the command
+// line that made it.
+type Tx struct {
+	before  Plugins
+	undo    []func() error
+	cleanup []func() error
+	notes   []string
+	undoes  []int
+}
+
+// Begin starts a transaction on the plugins as they are now.
+func Begin(p Plugins) *Tx {
+	before := make(Plugins, len(p))
+	for name, plugin := range p {
+		before[name] = plugin
+	}
+	return &Tx{before: before}
+}
+
+// OnRollback records how to undo a step that has been done.
+func (tx *Tx) OnRollback(undo func() error) {
+	tx.undo = append(tx.undo, undo)
+}
+
+// Note records something the transaction did besides changing plugins,
+// for the journal.
+func (tx *Tx) Note(format string, a ...interface{}) {
+	tx.notes = append(tx.notes, fmt.Sprintf(format, a...))
+}
+
+// MarkUndo records that the transaction undoes the given journal entries.
+func (tx *Tx) MarkUndo(ids []int) {
+	tx.undoes = append(tx.undoes, ids...)
+}
+
+// Clone clones a plugin, removing the clone on rollback.
+func (tx *Tx) Clone(plugin *Plugin) (string, error) {
+	dir := plugin.Dir()
+	if _, err := Filesys.Stat(dir); err == nil {
+		return "", fmt.Errorf("%s already exists", dir)
+	}
+	out, err := plugin.CloneRepo()
+	if err != nil {
+		// a clone that was interrupted may leave a partial directory behind
+		Filesys.RemoveAll(dir)
+		return out, err
+	}
+	tx.OnRollback(func() error {
+		return Filesys.RemoveAll(dir)
+	})
+	return out, nil
+}
+
+// Rename renames a file or directory, renaming it back on rollback.
+func (tx *Tx) Rename(oldpath, newpath string) error {
+	if _, err := Filesys.Stat(newpath); err == nil {
+		return fmt.Errorf("%s already exists", newpath)
+	}
+	if err := Filesys.Rename(oldpath, newpath); err != nil {
+		return err
+	}
+	tx.OnRollback(func() error {
+		return Filesys.Rename(newpath, oldpath)
+	})
+	return nil
+}
+
+// SetOriginURL points a clone's origin at the plugin's URL, pointing it
+// back on rollback.
+func (tx *Tx) SetOriginURL(plugin *Plugin) error {
+	origin, err := plugin.OriginURL()
+	if err != nil {
+		return err
+	}
+	if err := plugin.SetOriginURL(); err != nil {
+		return err
+	}
+	tx.OnRollback(func() error {
+		_, err := plugin.RunGit("remote", "set-url", "origin", origin)
+		return err
+	})
+	return nil
+}
+
+// RemoveAll removes a file or directory. Until the transaction is committed
+// it is only moved aside, so that rollback can put it back.
+func (tx *Tx) RemoveAll(path string) error {
+	aside, err := tempDir(filepath.Dir(path), "."+filepath.Base(path)+".removed")
+	if err != nil {
+		return fmt.Errorf("failed to move %s aside: %w", path, err)
+	}
+	moved := filepath.Join(aside, filepath.Base(path))
+	if err := Filesys.Rename(path, moved); err != nil {
+		Filesys.Remove(aside)
+		return err
+	}
+	tx.OnRollback(func() error {
+		if err := Filesys.Rename(moved, path); err != nil {
+			return err
+		}
+		return Filesys.Remove(aside)
+	})
+	tx.cleanup = append(tx.cleanup, func() error {
+		return Filesys.RemoveAll(aside)
+	})
+	return nil
+}
+
+// tempDir creates a new directory in dir whose name starts with prefix.
+func tempDir(dir, prefix string) (string, error) {
+	for i := 0; ; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s%d", prefix, i))
+		err := Filesys.Mkdir(path, 0o700)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+// Commit writes the plugins file and rebuilds the config. If either fails
+// the transaction is rolled back. Nothing is written while a bisect is in
+// progress, since that would replace the configuration it is testing.
+func (tx *Tx) Commit(p Plugins) error {
+	if err := CheckNoBisect(); err != nil {
+		return tx.fail(err)
+	}
+	if err := p.Write(); err != nil {
+		return tx.fail(err)
+	}
+	tx.OnRollback(tx.before.Write)
+	if err := p.RebuildConfig(); err != nil {
+		return tx.fail(fmt.Errorf("failed to rebuild configuration: %w", err))
+	}
+	tx.OnRollback(tx.before.RebuildConfig)
+	if err := tx.journal(p); err != nil {
+		return tx.fail(err)
+	}
+	tx.undo = nil
+	failed := []string{}
+	for _, cleanup := range tx.cleanup {
+		if err := cleanup(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	tx.cleanup = nil
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to clean up: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// journal records the transaction, unless it changed nothing.
+func (tx *Tx) journal(p Plugins) error {
+	changes := DiffPlugins(tx.before, p)
+	if len(changes) == 0 && len(tx.notes) == 0 {
+		return nil
+	}
+	_, err := AppendJournal(JournalEntry{
+		Time:    time.Now(),
+		Command: filepath.Base(os.Args[0]),
+		Args:    os.Args[1:],
+		Changes: changes,
+		Notes:   tx.notes,
+		Undoes:  tx.undoes,
+	})
+	return err
+}
+
+// Rollback undoes the steps done so far, most recent first. Every step is
+// tried even if undoing another fails.
+func (tx *Tx) Rollback() error {
+	failed := []string{}
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	tx.undo = nil
+	tx.cleanup = nil
+	if len(failed) > 0 {
+		return fmt.Errorf("rollback failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// fail rolls back and returns err, along with any rollback failure.
+func (tx *Tx) fail(err error) error {
+	if rerr := tx.Rollback(); rerr != nil {
+		return fmt.Errorf("%w (%s)", err, rerr)
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tools "github.com/WhoIsSethDaniel/vim-tools"
+	"github.com/spf13/afero"
+)
+
+func TestTransaction(t *testing.T) {
+	prepareEnv(t)
+
+	plugins := tools.Plugins{
+		"old": {Name: "old", URL: "https://github.com/user/old", CleanName: "old", ConfigFile: "old.lua", Enabled: true},
+	}
+	if err := plugins.Write(); err != nil {
+		t.Fatal(err)
+	}
+	oldDir := filepath.Join(tools.PluginDir(), "old")
+	newDir := filepath.Join(tools.PluginDir(), "new")
+	goneDir := filepath.Join(tools.PluginDir(), "gone")
+	for _, dir := range []string{oldDir, goneDir} {
+		if err := afero.WriteFile(tools.Filesys, filepath.Join(dir, "README"), []byte(dir), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(path string) bool {
+		_, err := tools.Filesys.Stat(path)
+		return err == nil
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		tx := tools.Begin(plugins)
+		if err := tx.Rename(oldDir, newDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.RemoveAll(goneDir); err != nil {
+			t.Fatal(err)
+		}
+		if exists(oldDir) || exists(goneDir) {
+			t.Fatal("directories not moved")
+		}
+		order := []string{}
+		tx.OnRollback(func() error {
+			order = append(order, "last")
+			return nil
+		})
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if !exists(filepath.Join(oldDir, "README")) || !exists(filepath.Join(goneDir, "README")) || exists(newDir) {
+			t.Error("directories not restored")
+		}
+		if ents, _ := afero.ReadDir(tools.Filesys, tools.PluginDir()); len(ents) != 2 {
+			t.Errorf("got %d entries in plugin dir, want 2", len(ents))
+		}
+		if !reflect.DeepEqual(order, []string{"last"}) {
+			t.Errorf("got %v", order)
+		}
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		tx := tools.Begin(plugins)
+		renamed := tools.Plugins{"new": plugins["old"]}
+		if err := tx.Rename(oldDir, newDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.RemoveAll(goneDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(renamed); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tools.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, renamed) {
+			t.Errorf("got %v, want %v", got, renamed)
+		}
+		if ents, _ := afero.ReadDir(tools.Filesys, tools.PluginDir()); len(ents) != 1 || ents[0].Name() != "new" {
+			t.Errorf("got %v in plugin dir, want only new", ents)
+		}
+	})
+
+	t.Run("rename over existing", func(t *testing.T) {
+		tx := tools.Begin(plugins)
+		if err := tx.Rename(newDir, newDir); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestTxSetOriginURL(t *testing.T) {
+	prepareGitEnv(t)
+	upstream := upstreamRepo(t, 1)
+	plugin := clonePlugin(t, upstream, "plugin")
+
+	moved := plugin.SetURL("https://example.com/user/plugin")
+	tx := tools.Begin(tools.Plugins{"plugin": plugin})
+	if err := tx.SetOriginURL(&moved); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, plugin.Dir(), "remote", "get-url", "origin"); got != moved.URL {
+		t.Errorf("origin is %s, want %s", got, moved.URL)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if got := git(t, plugin.Dir(), "remote", "get-url", "origin"); got != upstream {
+		t.Errorf("origin is %s after rollback, want %s", got, upstream)
+	}
+}